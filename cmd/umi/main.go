@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra"
+	"github.com/gong023/umi/usecase"
+)
+
+func main() {
+	logger := domain.NewSimpleLogger()
+
+	// Read the secrets from the environment variables
+	discordToken := os.Getenv("DISCORD_TOKEN")
+	openaiAPIKey := os.Getenv("OPENAI_API_KEY")
+	if discordToken == "" || openaiAPIKey == "" {
+		logger.Error("DISCORD_TOKEN and OPENAI_API_KEY are required")
+		os.Exit(1)
+	}
+
+	discordClient, err := newDiscordClient(discordToken, logger)
+	if err != nil {
+		logger.Error("Failed to create Discord client: %v", err)
+		os.Exit(1)
+	}
+	openaiClient := infra.NewOpenAIClient(openaiAPIKey, logger)
+	fileSystem := infra.NewFileSystem(logger, infra.NewFileLock(logger))
+	gameStore := infra.NewGameStore(fileSystem, logger)
+	leakGuard := usecase.NewLeakGuard(fileSystem, logger)
+
+	botService := usecase.NewBotService(discordClient, openaiClient, logger)
+	if err := configure(botService, discordClient, openaiClient, fileSystem, gameStore, leakGuard, logger); err != nil {
+		logger.Error("Invalid configuration: %v", err)
+		os.Exit(1)
+	}
+
+	if err := botService.Start(); err != nil {
+		logger.Error("Failed to start bot: %v", err)
+		os.Exit(1)
+	}
+
+	// Wait until the bot is asked to stop, by Ctrl+C or systemd
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	received := <-stop
+	logger.Info("Received %s, shutting down", received)
+
+	if err := botService.Stop(); err != nil {
+		logger.Error("Failed to stop bot: %v", err)
+		os.Exit(1)
+	}
+}
+
+// newDiscordClient receives the interactions over HTTP instead of the gateway when the address is given
+func newDiscordClient(token string, logger domain.Logger) (domain.DiscordClient, error) {
+	addr := os.Getenv("UMI_HTTP_ADDR")
+	if addr == "" {
+		return infra.NewDiscordClient(token, logger)
+	}
+	return infra.NewHTTPDiscordClient(token, os.Getenv("DISCORD_PUBLIC_KEY"), addr, logger)
+}
+
+// configure registers the handlers and applies the settings from the environment variables
+func configure(
+	botService *usecase.BotService,
+	discordClient domain.DiscordClient,
+	openaiClient domain.OpenAIClient,
+	fileSystem domain.FileSystem,
+	gameStore domain.GameStore,
+	leakGuard *usecase.LeakGuard,
+	logger domain.Logger,
+) error {
+	env := &environment{}
+
+	// The replies are sent by infra for both of the gateway and the HTTP endpoint
+	client, isGateway := discordClient.(*infra.DiscordClient)
+	if httpClient, ok := discordClient.(*infra.HTTPDiscordClient); ok {
+		client = httpClient.DiscordClient
+	}
+	if limit := env.Int("UMI_ATTACHMENT_LIMIT"); limit > 0 {
+		client.SetAttachmentLimit(limit)
+	}
+
+	botService.SetCommandGuild(os.Getenv("UMI_GUILD_ID"))
+	if timeout := env.Duration("UMI_SHUTDOWN_TIMEOUT"); timeout > 0 {
+		botService.SetShutdownTimeout(timeout)
+	}
+
+	// Run the handlers on the worker pool
+	dispatcher := usecase.NewDispatcher(logger)
+	if workers := env.Int("UMI_WORKERS"); workers > 0 {
+		dispatcher.SetWorkers(workers)
+	}
+	if size := env.Int("UMI_QUEUE_SIZE"); size > 0 {
+		dispatcher.SetQueueSize(size)
+	}
+	dispatcher.SetStatsInterval(env.Duration("UMI_STATS_INTERVAL"))
+	botService.SetDispatcher(dispatcher)
+
+	// Limit the commands calling the OpenAI API
+	rateLimiter := usecase.NewRateLimiter(logger)
+	rateLimiter.SetQuestionLimit(env.Int("UMI_QUESTIONS_PER_MINUTE"))
+	for _, command := range []string{"create", "q", "answer", "clue", "info", "giveup"} {
+		prefix := "UMI_" + strings.ToUpper(command)
+		cooldown := &usecase.Cooldown{
+			PerUser:    env.Duration(prefix + "_USER_COOLDOWN"),
+			PerChannel: env.Duration(prefix + "_CHANNEL_COOLDOWN"),
+		}
+		if cooldown.PerUser > 0 || cooldown.PerChannel > 0 {
+			rateLimiter.SetCooldown(command, cooldown)
+		}
+	}
+
+	botService.Use(
+		usecase.LoggingMiddleware(logger),
+		usecase.NewLatency(logger).Middleware(),
+		usecase.RecoverMiddleware(logger),
+		rateLimiter.Middleware(),
+	)
+
+	// Create the command handlers
+	create := usecase.NewCreateCommandHandler(openaiClient, fileSystem, gameStore, logger)
+	create.SetThreadPerGame(env.Bool("UMI_THREAD_PER_GAME"))
+	q := usecase.NewQCommandHandler(openaiClient, fileSystem, gameStore, leakGuard, logger)
+	answer := usecase.NewAnswerCommandHandler(openaiClient, fileSystem, gameStore, leakGuard, logger)
+	if threshold := env.Float("UMI_KEY_POINT_THRESHOLD"); threshold > 0 {
+		answer.SetKeyPointThreshold(threshold)
+	}
+	if samples := env.Int("UMI_JUDGE_SAMPLES"); samples > 1 {
+		// A verdict needs the majority of the samples unless the agreement is given
+		agreement := env.Float("UMI_JUDGE_AGREEMENT")
+		if agreement <= 0 {
+			agreement = 0.5
+		}
+		answer.SetJudgeVoting(samples, agreement)
+	}
+	clue := usecase.NewClueCommandHandler(openaiClient, fileSystem, gameStore, leakGuard, logger)
+	info := usecase.NewInfoCommandHandler(openaiClient, fileSystem, gameStore, logger)
+	giveup := usecase.NewGiveupCommandHandler(openaiClient, fileSystem, gameStore, logger)
+	quit := usecase.NewQuitCommandHandler(fileSystem, gameStore, logger)
+
+	// Restrict the commands ending the game
+	roles := env.List("UMI_GAME_MASTER_ROLES")
+	authorizer := usecase.NewAuthorizer(gameStore, logger)
+	for command, variable := range map[string]string{"giveup": "UMI_GIVEUP_POLICY", "quit": "UMI_QUIT_POLICY"} {
+		policy, err := usecase.ParsePolicy(os.Getenv(variable), roles)
+		if err != nil {
+			return err
+		}
+		authorizer.SetPolicy(command, policy)
+	}
+	giveup.SetAuthorizer(authorizer)
+	quit.SetAuthorizer(authorizer)
+
+	// Decide the commands ending the game by the vote of the players, which the vote rule of the policies needs too
+	if env.Bool("UMI_VOTING") || authorizer.NeedsVoting() {
+		voting := usecase.NewVoting(gameStore, logger)
+		if share := env.Float("UMI_VOTE_SHARE"); share > 0 {
+			voting.SetShare(share)
+		}
+		if duration := env.Duration("UMI_VOTE_DURATION"); duration > 0 {
+			voting.SetDuration(duration)
+		}
+		giveup.SetVoting(voting)
+		quit.SetVoting(voting)
+		botService.RegisterComponent(usecase.CustomIDVoteYes, voting)
+		botService.RegisterComponent(usecase.CustomIDVoteNo, voting)
+		botService.RegisterStartHook(voting.Resume)
+	}
+
+	// Register the commands
+	botService.RegisterCommand(usecase.NewPingCommandHandler(logger))
+	botService.RegisterCommand(usecase.NewHelpCommandHandler(logger))
+	botService.RegisterCommand(usecase.NewQuizCommandHandler(openaiClient, logger))
+	botService.RegisterCommand(create)
+	botService.RegisterCommand(q)
+	botService.RegisterCommand(answer)
+	botService.RegisterCommand(clue)
+	botService.RegisterCommand(info)
+	botService.RegisterCommand(giveup)
+	botService.RegisterCommand(quit)
+
+	// Register the buttons and the modal, which share the limits of their commands
+	botService.RegisterComponent(usecase.CustomIDClue, rateLimiter.Limit("clue", clue))
+	botService.RegisterComponent(usecase.CustomIDInfo, info)
+	botService.RegisterComponent(usecase.CustomIDGiveup, usecase.NewGiveupConfirmHandler(logger))
+	botService.RegisterComponent(usecase.CustomIDGiveupConfirm, giveup)
+	botService.RegisterComponent(usecase.CustomIDCancel, usecase.NewCancelHandler(logger))
+	botService.RegisterComponent(usecase.CustomIDAnswer, answer)
+	botService.RegisterComponent(usecase.CustomIDAnswerModal, rateLimiter.Limit("answer", answer))
+
+	// Read the questions and the answers from the plain messages
+	if env.Bool("UMI_MESSAGE_MODE") {
+		// The messages are delivered only over the gateway
+		if !isGateway {
+			return errors.New("UMI_MESSAGE_MODE can not be used with UMI_HTTP_ADDR")
+		}
+		client.EnableMessageContent()
+
+		// The messages run the commands through the middlewares, which limit them as the commands
+		messageMode := usecase.NewMessageModeHandler(botService.Handler(), gameStore, logger)
+		if marker := os.Getenv("UMI_ANSWER_MARKER"); marker != "" {
+			messageMode.SetAnswerMarker(marker)
+		}
+		botService.RegisterMessageHandler(messageMode)
+	}
+
+	return env.err
+}
+
+// environment reads the optional settings, and keeps the first invalid one as the error
+type environment struct {
+	err error
+}
+
+func (e *environment) fail(name string, err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf("%s: %w", name, err)
+	}
+}
+
+func (e *environment) Bool(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.fail(name, err)
+	}
+	return parsed
+}
+
+func (e *environment) Int(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.fail(name, err)
+	}
+	return parsed
+}
+
+func (e *environment) Float(name string) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.fail(name, err)
+	}
+	return parsed
+}
+
+func (e *environment) Duration(name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.fail(name, err)
+	}
+	return parsed
+}
+
+// List reads the values separated by commas
+func (e *environment) List(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
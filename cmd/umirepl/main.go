@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra"
+	"github.com/gong023/umi/usecase"
+)
+
+// umirepl plays the games in the terminal with the same handlers as the bot.
+// Run it in the root of the repository, where memo/prompt is read from. The game
+// is stored in a temporary directory, or in the -dir one, apart from the memo of
+// the bot.
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "umirepl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run plays the games until the input ends, and returns the error which stops it
+// early, so that the deferred cleanups run before main exits
+func run() error {
+	openaiMode := flag.String("openai", "fake", "The OpenAI client: fake, real or replay")
+	record := flag.String("record", "", "The file to record the OpenAI responses to")
+	replay := flag.String("replay", "", "The file to replay the OpenAI responses from, with -openai replay")
+	locale := flag.String("locale", "ja", "The locale of the player")
+	messages := flag.Bool("messages", false, "Read the plain lines as the questions and the answers")
+	dir := flag.String("dir", "", "The directory the game is stored in, a new temporary one by default")
+	verbose := flag.Bool("v", false, "Show the info logs")
+	flag.Parse()
+
+	var logger domain.Logger = domain.NewSimpleLogger()
+	if !*verbose {
+		logger = &quietLogger{SimpleLogger: domain.NewSimpleLogger()}
+	}
+
+	openaiClient, err := newOpenAIClient(*openaiMode, *replay, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create OpenAI client: %w", err)
+	}
+	if *record != "" {
+		file, err := os.Create(*record)
+		if err != nil {
+			return fmt.Errorf("failed to create the record file: %w", err)
+		}
+		defer file.Close()
+		openaiClient = infra.NewRecordingOpenAIClient(openaiClient, file, logger)
+	}
+
+	terminal := infra.NewTerminalClient(os.Stdin, os.Stdout, logger)
+	terminal.SetLocale(*locale)
+
+	// Keep the game apart from the memo of the bot, with the prompts of the repository
+	stateDir, cleanup, err := prepareStateDir(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to prepare the state directory: %w", err)
+	}
+	defer cleanup()
+	logger.Info("Storing the game in %s", stateDir)
+
+	// The memo of the handlers is read and written in the state directory
+	fileSystem := infra.NewFileSystem(logger, infra.NewFileLock(logger))
+	fileSystem.SetRoot(stateDir)
+	gameStore := infra.NewGameStore(fileSystem, logger)
+	leakGuard := usecase.NewLeakGuard(fileSystem, logger)
+
+	// The handlers run one at a time without the dispatcher, so that the output keeps the order of the input
+	botService := usecase.NewBotService(terminal, openaiClient, logger)
+	botService.Use(usecase.LoggingMiddleware(logger), usecase.RecoverMiddleware(logger))
+
+	create := usecase.NewCreateCommandHandler(openaiClient, fileSystem, gameStore, logger)
+	q := usecase.NewQCommandHandler(openaiClient, fileSystem, gameStore, leakGuard, logger)
+	answer := usecase.NewAnswerCommandHandler(openaiClient, fileSystem, gameStore, leakGuard, logger)
+	clue := usecase.NewClueCommandHandler(openaiClient, fileSystem, gameStore, leakGuard, logger)
+	info := usecase.NewInfoCommandHandler(openaiClient, fileSystem, gameStore, logger)
+	giveup := usecase.NewGiveupCommandHandler(openaiClient, fileSystem, gameStore, logger)
+
+	botService.RegisterCommand(usecase.NewPingCommandHandler(logger))
+	botService.RegisterCommand(usecase.NewHelpCommandHandler(logger))
+	botService.RegisterCommand(usecase.NewQuizCommandHandler(openaiClient, logger))
+	botService.RegisterCommand(create)
+	botService.RegisterCommand(q)
+	botService.RegisterCommand(answer)
+	botService.RegisterCommand(clue)
+	botService.RegisterCommand(info)
+	botService.RegisterCommand(giveup)
+	botService.RegisterCommand(usecase.NewQuitCommandHandler(fileSystem, gameStore, logger))
+
+	botService.RegisterComponent(usecase.CustomIDClue, clue)
+	botService.RegisterComponent(usecase.CustomIDInfo, info)
+	botService.RegisterComponent(usecase.CustomIDGiveup, usecase.NewGiveupConfirmHandler(logger))
+	botService.RegisterComponent(usecase.CustomIDGiveupConfirm, giveup)
+	botService.RegisterComponent(usecase.CustomIDCancel, usecase.NewCancelHandler(logger))
+	botService.RegisterComponent(usecase.CustomIDAnswer, answer)
+	botService.RegisterComponent(usecase.CustomIDAnswerModal, answer)
+
+	if *messages {
+		botService.RegisterMessageHandler(usecase.NewMessageModeHandler(botService.Handler(), gameStore, logger))
+	}
+
+	if err := botService.Start(); err != nil {
+		return fmt.Errorf("failed to start bot: %w", err)
+	}
+
+	// Stop the bot even when the input fails, so that the game in flight is saved
+	runErr := terminal.Run()
+	if err := botService.Stop(); err != nil {
+		return fmt.Errorf("failed to stop bot: %w", err)
+	}
+	if runErr != nil {
+		return fmt.Errorf("failed to read the input: %w", runErr)
+	}
+
+	return nil
+}
+
+// prepareStateDir copies the prompts into the directory, or into a new temporary one
+// when it is empty, so that the handlers read and write their memo there. The
+// returned function removes the temporary directory.
+func prepareStateDir(dir string) (string, func(), error) {
+	prompts, err := filepath.Abs(filepath.Join("memo", "prompt"))
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := os.Stat(prompts); err != nil {
+		return "", nil, fmt.Errorf("no prompts in the working directory: %w", err)
+	}
+
+	cleanup := func() {}
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "umirepl-"); err != nil {
+			return "", nil, err
+		}
+		temporary := dir
+		cleanup = func() { os.RemoveAll(temporary) }
+	}
+
+	// The prompts already in the directory are kept, so that they can be tried out there
+	target := filepath.Join(dir, "memo", "prompt")
+	if _, err := os.Stat(target); os.IsNotExist(err) {
+		if err := os.CopyFS(target, os.DirFS(prompts)); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("failed to copy the prompts: %w", err)
+		}
+	}
+
+	return dir, cleanup, nil
+}
+
+func newOpenAIClient(mode string, replay string, logger domain.Logger) (domain.OpenAIClient, error) {
+	switch mode {
+	case "fake":
+		return infra.NewFakeOpenAIClient(logger), nil
+	case "real":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY is required")
+		}
+		return infra.NewOpenAIClient(apiKey, logger), nil
+	case "replay":
+		file, err := os.Open(replay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the replay file: %w", err)
+		}
+		defer file.Close()
+		return infra.NewReplayOpenAIClient(file, logger)
+	default:
+		return nil, fmt.Errorf("unknown OpenAI client: %s", mode)
+	}
+}
+
+// quietLogger shows only the errors, which would otherwise be buried in the logs of every step
+type quietLogger struct {
+	*domain.SimpleLogger
+}
+
+func (l *quietLogger) Info(format string, args ...interface{}) {}
+
+func (l *quietLogger) Debug(format string, args ...interface{}) {}
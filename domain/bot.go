@@ -7,5 +7,25 @@ type BotService interface {
 }
 
 type CommandRegistry interface {
-	RegisterCommand(name string, handler CommandHandler)
+	// RegisterCommand registers the command under the name of its definition
+	RegisterCommand(command Command)
+
+	// RegisterComponent routes the message components and the modals with the custom ID to the handler
+	RegisterComponent(customID string, handler CommandHandler)
+
+	// RegisterMessageHandler handles the plain messages with the handler
+	RegisterMessageHandler(handler MessageHandler)
+
+	// Use wraps every handler with the middlewares, the first one being the outermost
+	Use(middlewares ...Middleware)
+}
+
+// Middleware wraps a handler to run the common steps around it, such as logging
+type Middleware func(next CommandHandler) CommandHandler
+
+// HandlerFunc is a function used as a CommandHandler
+type HandlerFunc func(s Session, i *InteractionCreate)
+
+func (f HandlerFunc) Handle(s Session, i *InteractionCreate) {
+	f(s, i)
 }
@@ -1,8 +1,58 @@
 package domain
 
+// ApplicationCommand is the definition of a slash command registered to Discord
 type ApplicationCommand struct {
 	Name        string
 	Description string
+	Options     []*ApplicationCommandOption
+
+	// NameLocalizations and DescriptionLocalizations are keyed by the Discord locales such as "ja"
+	NameLocalizations        map[string]string
+	DescriptionLocalizations map[string]string
+
+	// DefaultMemberPermissions is the permission bit set required to use the command by default
+	// nil allows every member to use it
+	DefaultMemberPermissions *int64
+
+	// DMPermission tells whether the command can be used in direct messages
+	DMPermission bool
+}
+
+type ApplicationCommandOptionType int
+
+const (
+	ApplicationCommandOptionString  ApplicationCommandOptionType = 3
+	ApplicationCommandOptionInteger ApplicationCommandOptionType = 4
+	ApplicationCommandOptionBoolean ApplicationCommandOptionType = 5
+	ApplicationCommandOptionUser    ApplicationCommandOptionType = 6
+	ApplicationCommandOptionChannel ApplicationCommandOptionType = 7
+	ApplicationCommandOptionRole    ApplicationCommandOptionType = 8
+	ApplicationCommandOptionNumber  ApplicationCommandOptionType = 10
+)
+
+type ApplicationCommandOption struct {
+	Type        ApplicationCommandOptionType
+	Name        string
+	Description string
+	Required    bool
+	Choices     []*ApplicationCommandOptionChoice
+
+	// NameLocalizations and DescriptionLocalizations are keyed by the Discord locales such as "ja"
+	NameLocalizations        map[string]string
+	DescriptionLocalizations map[string]string
+
+	// MinValue and MaxValue limit integer and number options
+	MinValue *float64
+	MaxValue *float64
+
+	// MinLength and MaxLength limit string options
+	MinLength *int
+	MaxLength int
+}
+
+type ApplicationCommandOptionChoice struct {
+	Name  string
+	Value interface{}
 }
 
 type DiscordClient interface {
@@ -10,20 +60,116 @@ type DiscordClient interface {
 
 	Stop() error
 
-	RegisterHandler(handler interface{}) func()
+	// OnInteraction calls the handler with the interactions, converted from the Discord API
+	OnInteraction(handler func(s Session, i *InteractionCreate))
+
+	// OnMessage calls the handler with the messages posted to the channels the bot can see
+	OnMessage(handler func(s Session, m *MessageCreate))
 
-	RegisterCommands(commands []*ApplicationCommand) error
+	// Commands returns the commands registered to Discord
+	// An empty guildID means the global commands
+	Commands(guildID string) ([]*ApplicationCommand, error)
 
-	DeleteCommands() error
+	// OverwriteCommands replaces the registered commands with the given ones in a single request
+	// An empty guildID means the global commands
+	OverwriteCommands(guildID string, commands []*ApplicationCommand) error
+
+	// Session returns the session to send the messages outside of the interactions, such as on start
+	Session() Session
 }
 
 type CommandHandler interface {
 	Handle(s Session, i *InteractionCreate)
 }
 
+// Command is a CommandHandler which declares its own slash command definition
+type Command interface {
+	CommandHandler
+
+	Definition() *ApplicationCommand
+}
+
 type Session interface {
 	InteractionRespond(i *InteractionCreate, r *InteractionResponse) error
+
+	// InteractionResponseEdit edits the original response, such as a deferred response
+	InteractionResponseEdit(i *InteractionCreate, data *InteractionResponseData) error
+
 	FollowupMessage(i *InteractionCreate, content string) error
+
+	// FollowupResponse sends a follow-up message with the data, such as an ephemeral one
+	FollowupResponse(i *InteractionCreate, data *InteractionResponseData) error
+
+	// InteractionResponseDelete deletes the original response, such as a deferred response
+	InteractionResponseDelete(i *InteractionCreate) error
+
+	// StartThread starts a public thread in the channel and returns its ID
+	StartThread(channelID string, name string) (string, error)
+
+	// SendMessage posts a message to the channel or the thread outside of an interaction
+	SendMessage(channelID string, data *InteractionResponseData) error
+
+	// ArchiveThread archives and locks the thread
+	ArchiveThread(threadID string) error
+
+	// ReplyMessage posts a message to the channel as a reply to the message
+	ReplyMessage(channelID string, messageID string, data *InteractionResponseData) error
+}
+
+// User is a Discord user
+type User struct {
+	ID string
+
+	Username string
+}
+
+// Mention returns the text mentioning the user in a message
+func (u *User) Mention() string {
+	return "<@" + u.ID + ">"
+}
+
+// Member is a user in a guild
+type Member struct {
+	// Nick is the nickname of the member in the guild, or empty when not set
+	Nick string
+
+	// Roles are the IDs of the roles the member has
+	Roles []string
+}
+
+// HasRole returns true when the member has any of the roles
+func (m *Member) HasRole(roles ...string) bool {
+	for _, role := range m.Roles {
+		for _, wanted := range roles {
+			if role == wanted {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MessageHandler handles the plain messages posted to the channels
+type MessageHandler interface {
+	HandleMessage(s Session, m *MessageCreate)
+}
+
+// MessageCreate is a plain message posted to a channel or a thread
+type MessageCreate struct {
+	ID string
+
+	// ChannelID is the channel or the thread the message was posted to
+	ChannelID string
+
+	Content string
+
+	AuthorID string
+
+	// Bot is true when the message was posted by a bot, including this one
+	Bot bool
+
+	// Original is the original message object from the Discord API
+	Original interface{}
 }
 
 type InteractionCreate struct {
@@ -31,9 +177,32 @@ type InteractionCreate struct {
 
 	Type int
 
+	// GuildID is the guild the interaction was used in, or empty in the direct messages
+	GuildID string
+
+	// ChannelID is the channel or the thread the interaction was used in
+	ChannelID string
+
+	// User is the user who used the interaction
+	User *User
+
+	// Member is the guild member who used the interaction, or nil in the direct messages
+	Member *Member
+
 	Data *ApplicationCommandInteractionData
 
-	// Original is the original interaction object from the Discord API
+	// Component is set when a message component such as a button was used
+	Component *MessageComponentInteractionData
+
+	// Modal is set when a modal was submitted
+	Modal *ModalSubmitInteractionData
+
+	// Locale is the locale of the user, and GuildLocale is the one of the guild such as "ja" or "en-US"
+	Locale string
+
+	GuildLocale string
+
+	// Original is the original interaction object from the Discord API, which is used only by infra to respond
 	Original interface{}
 }
 
@@ -43,12 +212,72 @@ type ApplicationCommandInteractionData struct {
 	Options []*ApplicationCommandInteractionDataOption
 }
 
+// Option returns the option with the name, or nil when it was not given
+func (d *ApplicationCommandInteractionData) Option(name string) *ApplicationCommandInteractionDataOption {
+	for _, opt := range d.Options {
+		if opt.Name == name {
+			return opt
+		}
+	}
+	return nil
+}
+
 type ApplicationCommandInteractionDataOption struct {
+	Type ApplicationCommandOptionType
+
 	Name string
 
+	// Value is a string, a bool, or a float64 for the numbers as in the JSON from Discord
 	Value interface{}
 }
 
+// StringValue returns the value of the string option, or empty when it is not a string
+func (o *ApplicationCommandInteractionDataOption) StringValue() string {
+	value, _ := o.Value.(string)
+	return value
+}
+
+// BoolValue returns the value of the boolean option, or false when it is not a boolean
+func (o *ApplicationCommandInteractionDataOption) BoolValue() bool {
+	value, _ := o.Value.(bool)
+	return value
+}
+
+// IntValue returns the value of the integer option, or zero when it is not a number
+func (o *ApplicationCommandInteractionDataOption) IntValue() int64 {
+	switch value := o.Value.(type) {
+	case float64:
+		return int64(value)
+	case int64:
+		return value
+	case int:
+		return int64(value)
+	default:
+		return 0
+	}
+}
+
+type MessageComponentInteractionData struct {
+	CustomID string
+}
+
+type ModalSubmitInteractionData struct {
+	CustomID string
+
+	// Values maps the custom IDs of the text inputs to the submitted values
+	Values map[string]string
+}
+
+type InteractionType int
+
+const (
+	InteractionApplicationCommand InteractionType = 2
+
+	InteractionMessageComponent InteractionType = 3
+
+	InteractionModalSubmit InteractionType = 5
+)
+
 type InteractionResponse struct {
 	Type int
 
@@ -57,10 +286,128 @@ type InteractionResponse struct {
 
 type InteractionResponseData struct {
 	Content string
+
+	Embeds []*MessageEmbed
+
+	Components []*ActionsRow
+
+	Flags MessageFlags
+
+	// CustomID and Title are used by modals
+	CustomID string
+
+	Title string
+}
+
+type MessageFlags int
+
+const (
+	// MessageFlagsEphemeral makes the response visible only to the user who invoked the interaction
+	MessageFlagsEphemeral MessageFlags = 1 << 6
+)
+
+// ActionsRow is a row of message components attached to a message
+type ActionsRow struct {
+	Components []MessageComponent
+}
+
+// MessageComponent is a component placed in an ActionsRow, such as a Button
+type MessageComponent interface {
+	isMessageComponent()
+}
+
+type ButtonStyle int
+
+const (
+	PrimaryButton ButtonStyle = 1
+
+	SecondaryButton ButtonStyle = 2
+
+	SuccessButton ButtonStyle = 3
+
+	DangerButton ButtonStyle = 4
+)
+
+type Button struct {
+	Label string
+
+	Style ButtonStyle
+
+	// CustomID routes the interaction of the button to its handler
+	CustomID string
+
+	Disabled bool
+}
+
+func (*Button) isMessageComponent() {}
+
+type TextInputStyle int
+
+const (
+	TextInputShort TextInputStyle = 1
+
+	TextInputParagraph TextInputStyle = 2
+)
+
+// TextInput is a text field placed in a modal
+type TextInput struct {
+	CustomID string
+
+	Label string
+
+	Style TextInputStyle
+
+	Placeholder string
+
+	Required bool
+
+	MinLength int
+
+	MaxLength int
+}
+
+func (*TextInput) isMessageComponent() {}
+
+// MessageEmbed is a rich card shown in a message
+type MessageEmbed struct {
+	Title string
+
+	Description string
+
+	// Color is the colour of the left border as 0xRRGGBB
+	Color int
+
+	Fields []*MessageEmbedField
+
+	Footer *MessageEmbedFooter
+}
+
+type MessageEmbedField struct {
+	Name string
+
+	Value string
+
+	Inline bool
+}
+
+type MessageEmbedFooter struct {
+	Text string
 }
 
 type InteractionResponseType int
 
 const (
 	InteractionResponseChannelMessageWithSource InteractionResponseType = 4
+
+	// InteractionResponseDeferredChannelMessageWithSource shows "thinking…" until the response is edited
+	InteractionResponseDeferredChannelMessageWithSource InteractionResponseType = 5
+
+	// InteractionResponseDeferredMessageUpdate acknowledges the component without changing its message until it is edited
+	InteractionResponseDeferredMessageUpdate InteractionResponseType = 6
+
+	// InteractionResponseUpdateMessage edits the message the component is attached to
+	InteractionResponseUpdateMessage InteractionResponseType = 7
+
+	// InteractionResponseModal opens a modal dialog
+	InteractionResponseModal InteractionResponseType = 9
 )
@@ -11,9 +11,16 @@ type FileSystem interface {
 	// FileExists checks if a file exists at the given path
 	FileExists(path string) (bool, error)
 
+	// AppendFile appends data to a file at the given path, creating it when it does not exist,
+	// and returns the size of the file after the append
+	AppendFile(path string, data []byte, perm int) (int64, error)
+
 	// RemoveFile removes a file at the given path
 	RemoveFile(path string) error
 
+	// RenameFile renames a file, replacing the file at the new path if any
+	RenameFile(oldPath, newPath string) error
+
 	// JoinPath joins path elements into a single path
 	JoinPath(elem ...string) string
 }
@@ -0,0 +1,132 @@
+package domain
+
+import "time"
+
+// QAnswer is the normalized answer to a question about the quiz
+type QAnswer string
+
+const (
+	QAnswerYes          QAnswer = "yes"
+	QAnswerNo           QAnswer = "no"
+	QAnswerIrrelevant   QAnswer = "irrelevant"
+	QAnswerPartially    QAnswer = "partially"
+	QAnswerImportantYes QAnswer = "important-yes"
+)
+
+// QAnswers lists all the normalized answers in the order they are presented
+var QAnswers = []QAnswer{
+	QAnswerYes,
+	QAnswerNo,
+	QAnswerIrrelevant,
+	QAnswerPartially,
+	QAnswerImportantYes,
+}
+
+// Label returns the Japanese label shown to the players
+func (a QAnswer) Label() string {
+	switch a {
+	case QAnswerYes:
+		return "はい"
+	case QAnswerNo:
+		return "いいえ"
+	case QAnswerIrrelevant:
+		return "関係ありません"
+	case QAnswerPartially:
+		return "部分的にはい"
+	case QAnswerImportantYes:
+		return "重要！はい"
+	default:
+		return string(a)
+	}
+}
+
+// QuestionRecord is a question asked with /q and its normalized answer
+type QuestionRecord struct {
+	Question string    `json:"question"`
+	Answer   QAnswer   `json:"answer"`
+	Note     string    `json:"note,omitempty"`
+	AskedAt  time.Time `json:"asked_at"`
+
+	// UserID is the user who asked the question
+	UserID string `json:"user_id,omitempty"`
+}
+
+// ClueRecord is a clue given with /clue
+type ClueRecord struct {
+	Clue string `json:"clue"`
+
+	// UserID is the user who asked for the clue
+	UserID string `json:"user_id,omitempty"`
+
+	// Private is true when the clue was shown only to the user
+	Private bool `json:"private,omitempty"`
+
+	GivenAt time.Time `json:"given_at"`
+}
+
+// VoteRecord is the vote of the players to end the game, such as giving up
+type VoteRecord struct {
+	// Command is the command run when the vote passes
+	Command string `json:"command"`
+
+	// Yes and No are the users who voted for and against the command
+	Yes []string `json:"yes"`
+	No  []string `json:"no,omitempty"`
+
+	// Needed is the number of the votes for the command to pass
+	Needed int `json:"needed"`
+
+	// Voters is the number of the eligible voters, who are the recent participants
+	Voters int `json:"voters,omitempty"`
+
+	// ChannelID is the channel the vote was started in
+	ChannelID string `json:"channel_id,omitempty"`
+
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Game is the structured record of the current quiz
+// The solution and the key points are hidden from the players and only given to the model
+type Game struct {
+	Solution  string            `json:"solution,omitempty"`
+	KeyPoints []string          `json:"key_points,omitempty"`
+	Questions []*QuestionRecord `json:"questions,omitempty"`
+	Clues     []*ClueRecord     `json:"clues,omitempty"`
+
+	// CreatorID is the user who created the game
+	CreatorID string `json:"creator_id,omitempty"`
+
+	// Vote is the vote in progress, or nil
+	Vote *VoteRecord `json:"vote,omitempty"`
+
+	// ChannelID is the channel the game was created in
+	ChannelID string `json:"channel_id,omitempty"`
+
+	// ThreadID is the thread the game is played in, or empty when it is played in a channel
+	ThreadID string `json:"thread_id,omitempty"`
+}
+
+// SeenClues returns the clues the user has seen, which are the public ones and the private ones of the user
+func (g *Game) SeenClues(userID string) []*ClueRecord {
+	var seen []*ClueRecord
+	for _, clue := range g.Clues {
+		if !clue.Private || clue.UserID == userID {
+			seen = append(seen, clue)
+		}
+	}
+	return seen
+}
+
+// GameStore is an interface for persisting the record of the current quiz
+type GameStore interface {
+	// Load returns the record of the current quiz
+	// If no record exists, it returns an empty record
+	Load() (*Game, error)
+
+	// Save persists the record of the current quiz
+	Save(game *Game) error
+
+	// Delete removes the record of the current quiz
+	// If no record exists, it does nothing
+	Delete() error
+}
@@ -6,12 +6,19 @@ type ChatMessage struct {
 	Content string `json:"content"`
 }
 
+// ResponseFormat specifies the format of the completion, such as "json_object"
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
 // ChatCompletionRequest represents a request to the OpenAI chat completions API
 type ChatCompletionRequest struct {
-	Model       string        `json:"model"`
-	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature,omitempty"`
-	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	N              int             `json:"n,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 // ChatCompletionResponse represents a response from the OpenAI chat completions API
@@ -2,6 +2,8 @@ package infra
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gong023/umi/domain"
@@ -10,6 +12,12 @@ import (
 type DiscordClient struct {
 	session *discordgo.Session
 	logger  domain.Logger
+
+	// messageContent requests the privileged intent to read the content of the messages
+	messageContent bool
+
+	// attachmentLimit is the length of the replies sent as a file, or zero for the default
+	attachmentLimit int
 }
 
 func NewDiscordClient(token string, logger domain.Logger) (*DiscordClient, error) {
@@ -27,71 +35,98 @@ func NewDiscordClient(token string, logger domain.Logger) (*DiscordClient, error
 func (c *DiscordClient) Start() error {
 	c.logger.Info("Starting Discord client")
 	c.session.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsGuildIntegrations
+	if c.messageContent {
+		c.session.Identify.Intents |= discordgo.IntentMessageContent
+	}
 	return c.session.Open()
 }
 
+// EnableMessageContent requests the message content intent on start, which is
+// needed to read the questions posted as plain messages. The intent must be
+// enabled for the bot in the developer portal as well.
+func (c *DiscordClient) EnableMessageContent() {
+	c.messageContent = true
+}
+
 func (c *DiscordClient) Stop() error {
 	c.logger.Info("Stopping Discord client")
 	return c.session.Close()
 }
 
-func (c *DiscordClient) RegisterHandler(handler interface{}) func() {
-	return c.session.AddHandler(handler)
+// SetAttachmentLimit sets the length of the replies sent as a file instead of several messages
+func (c *DiscordClient) SetAttachmentLimit(limit int) {
+	c.attachmentLimit = limit
 }
 
-func (c *DiscordClient) RegisterCommands(commands []*domain.ApplicationCommand) error {
-	c.logger.Info("Registering %d commands", len(commands))
-
-	for _, cmd := range commands {
-		var options []*discordgo.ApplicationCommandOption
-
-		// Add appropriate options based on the command name
-		switch cmd.Name {
-		case "q", "answer":
-			// Add a string option for the question/answer
-			options = append(options, &discordgo.ApplicationCommandOption{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "message",
-				Description: "The message to send",
-				Required:    true,
-			})
-		}
+// newSession wraps the discordgo session with the settings of the replies
+func (c *DiscordClient) newSession(session *discordgo.Session) *Session {
+	discordSession := NewSession(session, c.logger)
+	if c.attachmentLimit > 0 {
+		discordSession.SetAttachmentLimit(c.attachmentLimit)
+	}
+	return discordSession
+}
 
-		_, err := c.session.ApplicationCommandCreate(c.session.State.User.ID, "", &discordgo.ApplicationCommand{
-			Name:        cmd.Name,
-			Description: cmd.Description,
-			Options:     options,
-		})
+// Session returns the session to send the messages outside of the interactions
+func (c *DiscordClient) Session() domain.Session {
+	return c.newSession(c.session)
+}
 
-		if err != nil {
-			c.logger.Error("Failed to register command %s: %v", cmd.Name, err)
-			return err
-		}
+func (c *DiscordClient) OnInteraction(handler func(s domain.Session, i *domain.InteractionCreate)) {
+	c.session.AddHandler(func(session *discordgo.Session, i *discordgo.InteractionCreate) {
+		c.handleInteraction(session, i, handler)
+	})
+}
 
-		c.logger.Info("Registered command: %s", cmd.Name)
+// handleInteraction converts the interaction to the domain model, and calls the handler with it
+func (c *DiscordClient) handleInteraction(session *discordgo.Session, i *discordgo.InteractionCreate, handler func(s domain.Session, i *domain.InteractionCreate)) {
+	interaction := ConvertInteraction(i)
+	if interaction == nil {
+		c.logger.Error("Failed to convert discordgo.InteractionCreate to domain.InteractionCreate")
+		return
 	}
 
-	return nil
+	handler(c.newSession(session), interaction)
 }
 
-func (c *DiscordClient) DeleteCommands() error {
-	c.logger.Info("Deleting all commands")
+func (c *DiscordClient) OnMessage(handler func(s domain.Session, m *domain.MessageCreate)) {
+	c.session.AddHandler(func(session *discordgo.Session, m *discordgo.MessageCreate) {
+		message := ConvertMessage(m)
+		if message == nil {
+			c.logger.Error("Failed to convert discordgo.MessageCreate to domain.MessageCreate")
+			return
+		}
+
+		handler(c.newSession(session), message)
+	})
+}
 
-	commands, err := c.session.ApplicationCommands(c.session.State.User.ID, "")
+func (c *DiscordClient) Commands(guildID string) ([]*domain.ApplicationCommand, error) {
+	registered, err := c.session.ApplicationCommands(c.session.State.User.ID, guildID)
 	if err != nil {
 		c.logger.Error("Failed to get commands: %v", err)
-		return err
+		return nil, err
+	}
+
+	commands := make([]*domain.ApplicationCommand, 0, len(registered))
+	for _, cmd := range registered {
+		commands = append(commands, convertRegisteredCommand(cmd))
 	}
 
-	c.logger.Info("Found %d commands to delete", len(commands))
+	return commands, nil
+}
+
+func (c *DiscordClient) OverwriteCommands(guildID string, commands []*domain.ApplicationCommand) error {
+	c.logger.Info("Overwriting %d commands", len(commands))
 
+	converted := make([]*discordgo.ApplicationCommand, 0, len(commands))
 	for _, cmd := range commands {
-		c.logger.Info("Deleting command: %s", cmd.Name)
-		err := c.session.ApplicationCommandDelete(c.session.State.User.ID, "", cmd.ID)
-		if err != nil {
-			c.logger.Error("Failed to delete command %s: %v", cmd.Name, err)
-			return err
-		}
+		converted = append(converted, convertCommand(cmd))
+	}
+
+	if _, err := c.session.ApplicationCommandBulkOverwrite(c.session.State.User.ID, guildID, converted); err != nil {
+		c.logger.Error("Failed to overwrite commands: %v", err)
+		return err
 	}
 
 	return nil
@@ -100,13 +135,78 @@ func (c *DiscordClient) DeleteCommands() error {
 type Session struct {
 	session *discordgo.Session
 	logger  domain.Logger
+
+	// attachmentLimit is the length of the content sent as a file instead of several messages
+	attachmentLimit int
+
+	// ephemeral is true when the response is shown only to the user, so that its follow-ups are as well
+	ephemeral bool
 }
 
-func NewSession(session *discordgo.Session) *Session {
+func NewSession(session *discordgo.Session, logger domain.Logger) *Session {
 	return &Session{
-		session: session,
-		logger:  domain.NewSimpleLogger(), // Use a simple logger for now
+		session:         session,
+		logger:          logger,
+		attachmentLimit: DefaultAttachmentLimit,
+	}
+}
+
+// SetAttachmentLimit sets the length of the content sent as a file instead of several messages
+func (s *Session) SetAttachmentLimit(limit int) {
+	s.attachmentLimit = limit
+}
+
+// splitContent splits the content over the limit of Discord into several messages.
+// The content too long even for several messages is attached as a file instead.
+func (s *Session) splitContent(content string) ([]string, []*discordgo.File) {
+	if utf8.RuneCountInString(content) > s.attachmentLimit {
+		s.logger.Info("Attaching the content of %d characters as a file", utf8.RuneCountInString(content))
+		return []string{""}, []*discordgo.File{
+			{
+				Name:        attachmentName,
+				ContentType: "text/plain; charset=utf-8",
+				Reader:      strings.NewReader(content),
+			},
+		}
+	}
+
+	chunks := splitMessage(content, MaxMessageLength)
+	if len(chunks) > 1 {
+		s.logger.Info("Splitting the content of %d characters into %d messages", utf8.RuneCountInString(content), len(chunks))
+	}
+	return chunks, nil
+}
+
+// followupChunks sends the rest of the split content as the follow-up messages in order
+func (s *Session) followupChunks(interaction *discordgo.Interaction, chunks []string) error {
+	var flags discordgo.MessageFlags
+	if s.ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	for _, chunk := range chunks {
+		if _, err := s.session.FollowupMessageCreate(interaction, true, &discordgo.WebhookParams{
+			Content: chunk,
+			Flags:   flags,
+		}); err != nil {
+			s.logger.Error("Failed to send followup message: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendChunks sends the rest of the split content to the channel in order
+func (s *Session) sendChunks(channelID string, chunks []string) error {
+	for _, chunk := range chunks {
+		if _, err := s.session.ChannelMessageSend(channelID, chunk); err != nil {
+			s.logger.Error("Failed to send message: %v", err)
+			return err
+		}
 	}
+
+	return nil
 }
 
 func (s *Session) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
@@ -117,19 +217,40 @@ func (s *Session) InteractionRespond(i *domain.InteractionCreate, r *domain.Inte
 
 			response := &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseType(r.Type),
-				Data: &discordgo.InteractionResponseData{
-					Content: r.Data.Content,
-				},
 			}
 
-			s.logger.Info("Sending response: Type=%d, Content=%s", response.Type, response.Data.Content)
+			// Deferred responses have no data until they are edited
+			var chunks []string
+			if r.Data != nil {
+				var files []*discordgo.File
+				chunks, files = s.splitContent(r.Data.Content)
+				response.Data = &discordgo.InteractionResponseData{
+					Content:    chunks[0],
+					Embeds:     convertEmbeds(r.Data.Embeds),
+					Components: convertComponents(r.Data.Components),
+					Flags:      discordgo.MessageFlags(r.Data.Flags),
+					CustomID:   r.Data.CustomID,
+					Title:      r.Data.Title,
+					Files:      files,
+				}
+				s.ephemeral = r.Data.Flags&domain.MessageFlagsEphemeral != 0
+				s.logger.Info("Sending response: Type=%d, Content=%s", response.Type, response.Data.Content)
+			} else {
+				s.logger.Info("Sending response: Type=%d", response.Type)
+			}
 
 			// Send the response - this is the key part
 			err := s.session.InteractionRespond(originalInteractionCreate.Interaction, response)
 			if err != nil {
 				s.logger.Error("Failed to respond to interaction: %v", err)
+				return err
 			}
-			return err
+
+			// Send the rest of the long content as the follow-ups
+			if len(chunks) > 1 {
+				return s.followupChunks(originalInteractionCreate.Interaction, chunks[1:])
+			}
+			return nil
 		} else {
 			s.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
 		}
@@ -139,39 +260,377 @@ func (s *Session) InteractionRespond(i *domain.InteractionCreate, r *domain.Inte
 	return fmt.Errorf("no original interaction available")
 }
 
+func (s *Session) InteractionResponseEdit(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	if i.Original != nil {
+		originalInteractionCreate, ok := i.Original.(*discordgo.InteractionCreate)
+		if ok {
+			s.logger.Info("Editing response for interaction: ID=%s", originalInteractionCreate.ID)
+
+			chunks, files := s.splitContent(data.Content)
+			content := chunks[0]
+			embeds := convertEmbeds(data.Embeds)
+			components := convertComponents(data.Components)
+			_, err := s.session.InteractionResponseEdit(originalInteractionCreate.Interaction, &discordgo.WebhookEdit{
+				Content:    &content,
+				Embeds:     &embeds,
+				Components: &components,
+				Files:      files,
+			})
+
+			if err != nil {
+				s.logger.Error("Failed to edit response: %v", err)
+				return err
+			}
+
+			// Send the rest of the long content as the follow-ups
+			return s.followupChunks(originalInteractionCreate.Interaction, chunks[1:])
+		} else {
+			s.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
+		}
+	}
+
+	s.logger.Error("No original interaction available, cannot edit response")
+	return fmt.Errorf("no original interaction available")
+}
+
 func (s *Session) FollowupMessage(i *domain.InteractionCreate, content string) error {
 	if i.Original != nil {
 		originalInteractionCreate, ok := i.Original.(*discordgo.InteractionCreate)
 		if ok {
 			s.logger.Info("Sending followup message for interaction: ID=%s", originalInteractionCreate.ID)
 
-			// Create a webhook message
+			// Create a webhook message, or several ones for the long content
+			chunks, files := s.splitContent(content)
+			if files != nil {
+				_, err := s.session.FollowupMessageCreate(originalInteractionCreate.Interaction, true, &discordgo.WebhookParams{
+					Files: files,
+				})
+				if err != nil {
+					s.logger.Error("Failed to send followup message: %v", err)
+				}
+				return err
+			}
+
+			return s.followupChunks(originalInteractionCreate.Interaction, chunks)
+		} else {
+			s.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
+		}
+	}
+
+	s.logger.Error("No original interaction available, cannot send followup message")
+	return fmt.Errorf("no original interaction available")
+}
+
+func (s *Session) FollowupResponse(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	if i.Original != nil {
+		originalInteractionCreate, ok := i.Original.(*discordgo.InteractionCreate)
+		if ok {
+			s.logger.Info("Sending followup response for interaction: ID=%s", originalInteractionCreate.ID)
+
+			// The first message carries the embeds and the components, and the rest of the long content follows it
+			chunks, files := s.splitContent(data.Content)
+			flags := discordgo.MessageFlags(data.Flags)
 			_, err := s.session.FollowupMessageCreate(originalInteractionCreate.Interaction, true, &discordgo.WebhookParams{
-				Content: content,
+				Content:    chunks[0],
+				Embeds:     convertEmbeds(data.Embeds),
+				Components: convertComponents(data.Components),
+				Flags:      flags,
+				Files:      files,
 			})
-
 			if err != nil {
 				s.logger.Error("Failed to send followup message: %v", err)
+				return err
 			}
-			return err
+
+			for _, chunk := range chunks[1:] {
+				if _, err := s.session.FollowupMessageCreate(originalInteractionCreate.Interaction, true, &discordgo.WebhookParams{
+					Content: chunk,
+					Flags:   flags,
+				}); err != nil {
+					s.logger.Error("Failed to send followup message: %v", err)
+					return err
+				}
+			}
+			return nil
 		} else {
 			s.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
 		}
 	}
 
-	s.logger.Error("No original interaction available, cannot send followup message")
+	s.logger.Error("No original interaction available, cannot send followup response")
+	return fmt.Errorf("no original interaction available")
+}
+
+func (s *Session) InteractionResponseDelete(i *domain.InteractionCreate) error {
+	if i.Original != nil {
+		originalInteractionCreate, ok := i.Original.(*discordgo.InteractionCreate)
+		if ok {
+			s.logger.Info("Deleting response for interaction: ID=%s", originalInteractionCreate.ID)
+
+			if err := s.session.InteractionResponseDelete(originalInteractionCreate.Interaction); err != nil {
+				s.logger.Error("Failed to delete response: %v", err)
+				return err
+			}
+			return nil
+		} else {
+			s.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
+		}
+	}
+
+	s.logger.Error("No original interaction available, cannot delete response")
 	return fmt.Errorf("no original interaction available")
 }
 
+func convertCommand(cmd *domain.ApplicationCommand) *discordgo.ApplicationCommand {
+	dmPermission := cmd.DMPermission
+	result := &discordgo.ApplicationCommand{
+		Name:                     cmd.Name,
+		NameLocalizations:        convertLocalizations(cmd.NameLocalizations),
+		Description:              cmd.Description,
+		DescriptionLocalizations: convertLocalizations(cmd.DescriptionLocalizations),
+		DefaultMemberPermissions: cmd.DefaultMemberPermissions,
+		DMPermission:             &dmPermission,
+	}
+
+	for _, opt := range cmd.Options {
+		option := &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionType(opt.Type),
+			Name:        opt.Name,
+			Description: opt.Description,
+			Required:    opt.Required,
+			MinValue:    opt.MinValue,
+			MinLength:   opt.MinLength,
+			MaxLength:   opt.MaxLength,
+		}
+		if opt.MaxValue != nil {
+			option.MaxValue = *opt.MaxValue
+		}
+		if localizations := convertLocalizations(opt.NameLocalizations); localizations != nil {
+			option.NameLocalizations = *localizations
+		}
+		if localizations := convertLocalizations(opt.DescriptionLocalizations); localizations != nil {
+			option.DescriptionLocalizations = *localizations
+		}
+
+		for _, choice := range opt.Choices {
+			option.Choices = append(option.Choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  choice.Name,
+				Value: choice.Value,
+			})
+		}
+
+		result.Options = append(result.Options, option)
+	}
+
+	return result
+}
+
+func (s *Session) StartThread(channelID string, name string) (string, error) {
+	s.logger.Info("Starting thread in channel: %s", channelID)
+
+	thread, err := s.session.ThreadStart(channelID, name, discordgo.ChannelTypeGuildPublicThread, 1440)
+	if err != nil {
+		s.logger.Error("Failed to start thread: %v", err)
+		return "", err
+	}
+
+	return thread.ID, nil
+}
+
+func (s *Session) SendMessage(channelID string, data *domain.InteractionResponseData) error {
+	s.logger.Info("Sending message to channel: %s", channelID)
+
+	chunks, files := s.splitContent(data.Content)
+	_, err := s.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    chunks[0],
+		Embeds:     convertEmbeds(data.Embeds),
+		Components: convertComponents(data.Components),
+		Files:      files,
+	})
+	if err != nil {
+		s.logger.Error("Failed to send message: %v", err)
+		return err
+	}
+
+	// Send the rest of the long content in order
+	return s.sendChunks(channelID, chunks[1:])
+}
+
+func (s *Session) ArchiveThread(threadID string) error {
+	s.logger.Info("Archiving thread: %s", threadID)
+
+	archived := true
+	locked := true
+	_, err := s.session.ChannelEditComplex(threadID, &discordgo.ChannelEdit{
+		Archived: &archived,
+		Locked:   &locked,
+	})
+	if err != nil {
+		s.logger.Error("Failed to archive thread: %v", err)
+	}
+	return err
+}
+
+func convertRegisteredCommand(cmd *discordgo.ApplicationCommand) *domain.ApplicationCommand {
+	result := &domain.ApplicationCommand{
+		Name:                     cmd.Name,
+		Description:              cmd.Description,
+		DefaultMemberPermissions: cmd.DefaultMemberPermissions,
+	}
+	if cmd.NameLocalizations != nil {
+		result.NameLocalizations = convertRegisteredLocalizations(*cmd.NameLocalizations)
+	}
+	if cmd.DescriptionLocalizations != nil {
+		result.DescriptionLocalizations = convertRegisteredLocalizations(*cmd.DescriptionLocalizations)
+	}
+	// Discord omits the DM permission when it is allowed
+	if cmd.DMPermission == nil || *cmd.DMPermission {
+		result.DMPermission = true
+	}
+
+	for _, opt := range cmd.Options {
+		option := &domain.ApplicationCommandOption{
+			Type:                     domain.ApplicationCommandOptionType(opt.Type),
+			Name:                     opt.Name,
+			NameLocalizations:        convertRegisteredLocalizations(opt.NameLocalizations),
+			Description:              opt.Description,
+			DescriptionLocalizations: convertRegisteredLocalizations(opt.DescriptionLocalizations),
+			Required:                 opt.Required,
+			MinValue:                 opt.MinValue,
+			MinLength:                opt.MinLength,
+			MaxLength:                opt.MaxLength,
+		}
+		if opt.MaxValue != 0 {
+			maxValue := opt.MaxValue
+			option.MaxValue = &maxValue
+		}
+
+		for _, choice := range opt.Choices {
+			option.Choices = append(option.Choices, &domain.ApplicationCommandOptionChoice{
+				Name:  choice.Name,
+				Value: choice.Value,
+			})
+		}
+
+		result.Options = append(result.Options, option)
+	}
+
+	return result
+}
+
+func convertRegisteredLocalizations(localizations map[discordgo.Locale]string) map[string]string {
+	if len(localizations) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(localizations))
+	for locale, text := range localizations {
+		result[string(locale)] = text
+	}
+	return result
+}
+
+func convertLocalizations(localizations map[string]string) *map[discordgo.Locale]string {
+	if len(localizations) == 0 {
+		return nil
+	}
+
+	result := make(map[discordgo.Locale]string, len(localizations))
+	for locale, text := range localizations {
+		result[discordgo.Locale(locale)] = text
+	}
+	return &result
+}
+
+func convertEmbeds(embeds []*domain.MessageEmbed) []*discordgo.MessageEmbed {
+	// Always return a non-nil slice so that editing a response clears the previous embeds
+	result := make([]*discordgo.MessageEmbed, 0, len(embeds))
+	for _, embed := range embeds {
+		converted := &discordgo.MessageEmbed{
+			Title:       embed.Title,
+			Description: embed.Description,
+			Color:       embed.Color,
+		}
+
+		for _, field := range embed.Fields {
+			converted.Fields = append(converted.Fields, &discordgo.MessageEmbedField{
+				Name:   field.Name,
+				Value:  field.Value,
+				Inline: field.Inline,
+			})
+		}
+
+		if embed.Footer != nil {
+			converted.Footer = &discordgo.MessageEmbedFooter{
+				Text: embed.Footer.Text,
+			}
+		}
+
+		result = append(result, converted)
+	}
+
+	return result
+}
+
+func convertComponents(rows []*domain.ActionsRow) []discordgo.MessageComponent {
+	// Always return a non-nil slice so that editing a response clears the previous components
+	result := make([]discordgo.MessageComponent, 0, len(rows))
+	for _, row := range rows {
+		converted := discordgo.ActionsRow{}
+
+		for _, component := range row.Components {
+			switch c := component.(type) {
+			case *domain.Button:
+				converted.Components = append(converted.Components, discordgo.Button{
+					Label:    c.Label,
+					Style:    discordgo.ButtonStyle(c.Style),
+					CustomID: c.CustomID,
+					Disabled: c.Disabled,
+				})
+			case *domain.TextInput:
+				converted.Components = append(converted.Components, discordgo.TextInput{
+					CustomID:    c.CustomID,
+					Label:       c.Label,
+					Style:       discordgo.TextInputStyle(c.Style),
+					Placeholder: c.Placeholder,
+					Required:    c.Required,
+					MinLength:   c.MinLength,
+					MaxLength:   c.MaxLength,
+				})
+			}
+		}
+
+		result = append(result, converted)
+	}
+
+	return result
+}
+
 func ConvertInteraction(i *discordgo.InteractionCreate) *domain.InteractionCreate {
 	if i == nil || i.Interaction == nil {
 		return nil
 	}
 
 	result := &domain.InteractionCreate{
-		ID:       i.ID,
-		Type:     int(i.Type),
-		Original: i, // Store the entire InteractionCreate object
+		ID:        i.ID,
+		Type:      int(i.Type),
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		Locale:    string(i.Locale),
+		Original:  i, // Store the entire InteractionCreate object
+	}
+	if i.GuildLocale != nil {
+		result.GuildLocale = string(*i.GuildLocale)
+	}
+
+	// The user is in the member in the guilds, and given directly in the direct messages
+	if i.Member != nil && i.Member.User != nil {
+		result.User = &domain.User{ID: i.Member.User.ID, Username: i.Member.User.Username}
+	} else if i.User != nil {
+		result.User = &domain.User{ID: i.User.ID, Username: i.User.Username}
+	}
+	if i.Member != nil {
+		result.Member = &domain.Member{Nick: i.Member.Nick, Roles: i.Member.Roles}
 	}
 
 	// Check if this is an application command interaction
@@ -189,6 +648,7 @@ func ConvertInteraction(i *discordgo.InteractionCreate) *domain.InteractionCreat
 			options := make([]*domain.ApplicationCommandInteractionDataOption, len(data.Options))
 			for j, opt := range data.Options {
 				options[j] = &domain.ApplicationCommandInteractionDataOption{
+					Type:  domain.ApplicationCommandOptionType(opt.Type),
 					Name:  opt.Name,
 					Value: opt.Value,
 				}
@@ -197,5 +657,76 @@ func ConvertInteraction(i *discordgo.InteractionCreate) *domain.InteractionCreat
 		}
 	}
 
+	// Check if this is a message component interaction such as a button
+	if i.Type == discordgo.InteractionMessageComponent {
+		result.Component = &domain.MessageComponentInteractionData{
+			CustomID: i.MessageComponentData().CustomID,
+		}
+	}
+
+	// Check if this is a modal submission, and collect the values of its text inputs
+	if i.Type == discordgo.InteractionModalSubmit {
+		data := i.ModalSubmitData()
+		result.Modal = &domain.ModalSubmitInteractionData{
+			CustomID: data.CustomID,
+			Values:   make(map[string]string),
+		}
+
+		for _, component := range data.Components {
+			row, ok := component.(*discordgo.ActionsRow)
+			if !ok {
+				continue
+			}
+			for _, rowComponent := range row.Components {
+				if input, ok := rowComponent.(*discordgo.TextInput); ok {
+					result.Modal.Values[input.CustomID] = input.Value
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func (s *Session) ReplyMessage(channelID string, messageID string, data *domain.InteractionResponseData) error {
+	s.logger.Info("Replying to message %s in channel: %s", messageID, channelID)
+
+	chunks, files := s.splitContent(data.Content)
+	_, err := s.session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    chunks[0],
+		Embeds:     convertEmbeds(data.Embeds),
+		Components: convertComponents(data.Components),
+		Files:      files,
+		Reference: &discordgo.MessageReference{
+			MessageID: messageID,
+			ChannelID: channelID,
+		},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		s.logger.Error("Failed to reply to message: %v", err)
+		return err
+	}
+
+	// Send the rest of the long content in order
+	return s.sendChunks(channelID, chunks[1:])
+}
+
+func ConvertMessage(m *discordgo.MessageCreate) *domain.MessageCreate {
+	if m == nil || m.Message == nil {
+		return nil
+	}
+
+	result := &domain.MessageCreate{
+		ID:        m.ID,
+		ChannelID: m.ChannelID,
+		Content:   m.Content,
+		Original:  m,
+	}
+	if m.Author != nil {
+		result.AuthorID = m.Author.ID
+		result.Bot = m.Author.Bot
+	}
+
 	return result
 }
@@ -0,0 +1,46 @@
+package infra
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gong023/umi/domain"
+)
+
+func TestConvertInteraction(t *testing.T) {
+	raw := `{
+		"id": "10",
+		"type": 2,
+		"guild_id": "guild-id",
+		"channel_id": "channel-id",
+		"locale": "ja",
+		"member": {"nick": "探偵", "roles": ["role-id"], "user": {"id": "user-id", "username": "player"}},
+		"data": {"id": "30", "name": "clue", "type": 1, "options": [{"name": "private", "type": 5, "value": true}]}
+	}`
+	i := &discordgo.InteractionCreate{}
+	if err := json.Unmarshal([]byte(raw), i); err != nil {
+		t.Fatalf("Failed to parse interaction: %v", err)
+	}
+
+	interaction := ConvertInteraction(i)
+
+	// Everything the handlers need is in the domain model
+	if interaction.GuildID != "guild-id" || interaction.ChannelID != "channel-id" || interaction.Locale != "ja" {
+		t.Errorf("Unexpected interaction: %+v", interaction)
+	}
+	if interaction.User == nil || interaction.User.ID != "user-id" {
+		t.Errorf("Expected the user of the member, got %+v", interaction.User)
+	}
+	if interaction.Member == nil || interaction.Member.Nick != "探偵" || !interaction.Member.HasRole("role-id") {
+		t.Errorf("Unexpected member: %+v", interaction.Member)
+	}
+
+	opt := interaction.Data.Option("private")
+	if opt == nil || opt.Type != domain.ApplicationCommandOptionBoolean || !opt.BoolValue() {
+		t.Errorf("Expected the boolean option, got %+v", opt)
+	}
+	if interaction.Data.Option("missing") != nil {
+		t.Errorf("Expected no option which was not given")
+	}
+}
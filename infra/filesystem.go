@@ -11,6 +11,9 @@ import (
 type FileSystem struct {
 	logger   domain.Logger
 	fileLock domain.FileLock
+
+	// root is the directory the relative paths are resolved from, or empty for the working directory
+	root string
 }
 
 // NewFileSystem creates a new FileSystem instance
@@ -21,6 +24,19 @@ func NewFileSystem(logger domain.Logger, fileLock domain.FileLock) *FileSystem {
 	}
 }
 
+// SetRoot resolves the relative paths from the directory instead of the working directory
+func (fs *FileSystem) SetRoot(dir string) {
+	fs.root = dir
+}
+
+// resolve returns the path on the disk, which is under the root when the path is relative
+func (fs *FileSystem) resolve(path string) string {
+	if fs.root == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(fs.root, path)
+}
+
 // ReadFile reads the content of a file at the given path
 func (fs *FileSystem) ReadFile(path string) ([]byte, error) {
 	fs.logger.Info("Reading file: %s", path)
@@ -28,6 +44,7 @@ func (fs *FileSystem) ReadFile(path string) ([]byte, error) {
 	var content []byte
 	var err error
 	
+	path = fs.resolve(path)
 	err = fs.fileLock.WithLock(path, func() error {
 		content, err = os.ReadFile(path)
 		if err != nil {
@@ -48,6 +65,7 @@ func (fs *FileSystem) ReadFile(path string) ([]byte, error) {
 func (fs *FileSystem) WriteFile(path string, data []byte, perm int) error {
 	fs.logger.Info("Writing file: %s", path)
 
+	path = fs.resolve(path)
 	return fs.fileLock.WithLock(path, func() error {
 		// Ensure the directory exists
 		dir := filepath.Dir(path)
@@ -71,6 +89,7 @@ func (fs *FileSystem) FileExists(path string) (bool, error) {
 	var exists bool
 	var err error
 	
+	path = fs.resolve(path)
 	err = fs.fileLock.WithLock(path, func() error {
 		_, err = os.Stat(path)
 		if err == nil {
@@ -92,10 +111,49 @@ func (fs *FileSystem) FileExists(path string) (bool, error) {
 	return exists, nil
 }
 
+// AppendFile appends data to a file at the given path, and returns the size of the file after the append
+func (fs *FileSystem) AppendFile(path string, data []byte, perm int) (int64, error) {
+	fs.logger.Info("Appending to file: %s", path)
+
+	var size int64
+	path = fs.resolve(path)
+	err := fs.fileLock.WithLock(path, func() error {
+		// Ensure the directory exists
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fs.logger.Error("Failed to create directory: %v", err)
+			return err
+		}
+
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.FileMode(perm))
+		if err != nil {
+			fs.logger.Error("Failed to open file: %v", err)
+			return err
+		}
+		defer file.Close()
+
+		if _, err := file.Write(data); err != nil {
+			fs.logger.Error("Failed to append to file: %v", err)
+			return err
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			fs.logger.Error("Failed to stat file: %v", err)
+			return err
+		}
+		size = info.Size()
+		return nil
+	})
+
+	return size, err
+}
+
 // RemoveFile removes a file at the given path
 func (fs *FileSystem) RemoveFile(path string) error {
 	fs.logger.Info("Removing file: %s", path)
 	
+	path = fs.resolve(path)
 	return fs.fileLock.WithLock(path, func() error {
 		if err := os.Remove(path); err != nil {
 			fs.logger.Error("Failed to remove file: %v", err)
@@ -105,6 +163,20 @@ func (fs *FileSystem) RemoveFile(path string) error {
 	})
 }
 
+// RenameFile renames a file, replacing the file at the new path if any
+func (fs *FileSystem) RenameFile(oldPath, newPath string) error {
+	fs.logger.Info("Renaming file: %s to %s", oldPath, newPath)
+
+	oldPath, newPath = fs.resolve(oldPath), fs.resolve(newPath)
+	return fs.fileLock.WithLock(oldPath, func() error {
+		if err := os.Rename(oldPath, newPath); err != nil {
+			fs.logger.Error("Failed to rename file: %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
 // JoinPath joins path elements into a single path
 func (fs *FileSystem) JoinPath(elem ...string) string {
 	return filepath.Join(elem...)
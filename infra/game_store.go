@@ -0,0 +1,75 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gong023/umi/domain"
+)
+
+// GameStore is an implementation of the domain.GameStore interface
+// It keeps the record of the current quiz as JSON in memo/game.json
+type GameStore struct {
+	fileSystem domain.FileSystem
+	logger     domain.Logger
+	path       string
+}
+
+// NewGameStore creates a new GameStore instance
+func NewGameStore(fileSystem domain.FileSystem, logger domain.Logger) *GameStore {
+	return &GameStore{
+		fileSystem: fileSystem,
+		logger:     logger,
+		path:       fileSystem.JoinPath("memo", "game.json"),
+	}
+}
+
+// Load returns the record of the current quiz
+func (s *GameStore) Load() (*domain.Game, error) {
+	exists, err := s.fileSystem.FileExists(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return &domain.Game{}, nil
+	}
+
+	content, err := s.fileSystem.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var game domain.Game
+	if err := json.Unmarshal(content, &game); err != nil {
+		s.logger.Error("Failed to unmarshal game record: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal game record: %w", err)
+	}
+
+	return &game, nil
+}
+
+// Save persists the record of the current quiz
+func (s *GameStore) Save(game *domain.Game) error {
+	content, err := json.MarshalIndent(game, "", "  ")
+	if err != nil {
+		s.logger.Error("Failed to marshal game record: %v", err)
+		return fmt.Errorf("failed to marshal game record: %w", err)
+	}
+
+	return s.fileSystem.WriteFile(s.path, content, 0644)
+}
+
+// Delete removes the record of the current quiz
+func (s *GameStore) Delete() error {
+	exists, err := s.fileSystem.FileExists(s.path)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return nil
+	}
+
+	return s.fileSystem.RemoveFile(s.path)
+}
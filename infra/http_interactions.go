@@ -0,0 +1,380 @@
+package infra
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gong023/umi/domain"
+)
+
+const (
+	// defaultResponseTimeout is how long the endpoint waits for the initial response,
+	// within the 3 seconds Discord waits for the endpoint. The bot acknowledges the
+	// interactions its handlers do not respond to sooner than this.
+	defaultResponseTimeout = 2500 * time.Millisecond
+
+	// maxTimestampSkew is how far the signed timestamp of a request may be from now,
+	// so that a request captured on the way can not be replayed later
+	maxTimestampSkew = 5 * time.Second
+
+	// shutdownTimeout is how long Stop waits for the requests being served
+	shutdownTimeout = 5 * time.Second
+
+	// maxRequestBody is the size of the largest interaction accepted by the endpoint
+	maxRequestBody = 1 << 20
+)
+
+// errAlreadyResponded is returned when the handler sends the initial response twice
+var errAlreadyResponded = errors.New("the interaction already has the initial response")
+
+// capturedResponse is the initial response the handler sent to the callback endpoint
+type capturedResponse struct {
+	contentType string
+	body        []byte
+}
+
+// pendingInteraction is an interaction received over HTTP whose initial response is not written yet
+type pendingInteraction struct {
+	// responses receives the initial response from the handler
+	responses chan *capturedResponse
+
+	// written is closed once the initial response is written to the HTTP response
+	written chan struct{}
+
+	// claimed is true once the handler started sending the initial response, which the endpoint then waits for
+	claimed bool
+}
+
+// HTTPDiscordClient receives the interactions as the HTTP requests from Discord
+// instead of the gateway websocket, which lets the bot run behind a reverse
+// proxy without a persistent connection. The requests are verified with the
+// public key of the application, and dispatched to the same handlers as the
+// gateway events. The initial response of a handler is sent back as the HTTP
+// response, while the edits and the follow-ups use the REST API as usual. The
+// bot acknowledges the interactions which its handlers do not respond to in
+// time, as it does for the gateway, so the endpoint only waits for the response.
+type HTTPDiscordClient struct {
+	*DiscordClient
+
+	publicKey       ed25519.PublicKey
+	addr            string
+	server          *http.Server
+	responseTimeout time.Duration
+
+	// handlers are the interaction handlers registered by the bot
+	handlers []func(s domain.Session, i *domain.InteractionCreate)
+
+	// pending are the interactions waiting for their initial response, by their token
+	pending map[string]*pendingInteraction
+
+	// now is replaced in the tests
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewHTTPDiscordClient creates the client serving the interactions endpoint on
+// the address. The public key is the hex encoded key shown in the developer portal.
+func NewHTTPDiscordClient(token string, publicKey string, addr string, logger domain.Logger) (*HTTPDiscordClient, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: %s", publicKey)
+	}
+
+	client, err := NewDiscordClient(token, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &HTTPDiscordClient{
+		DiscordClient:   client,
+		publicKey:       ed25519.PublicKey(key),
+		addr:            addr,
+		responseTimeout: defaultResponseTimeout,
+		pending:         make(map[string]*pendingInteraction),
+		now:             time.Now,
+	}
+
+	// Route the initial responses of the handlers to the HTTP responses
+	transport := client.session.Client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client.session.Client = &http.Client{
+		Timeout:   client.session.Client.Timeout,
+		Transport: &callbackTransport{client: c, next: transport},
+	}
+
+	return c, nil
+}
+
+// SetResponseTimeout sets how long the endpoint waits for the initial response before failing the interaction
+func (c *HTTPDiscordClient) SetResponseTimeout(timeout time.Duration) {
+	c.responseTimeout = timeout
+}
+
+// Start looks up the application for the command registration, and starts serving the endpoint
+func (c *HTTPDiscordClient) Start() error {
+	c.logger.Info("Starting HTTP interactions endpoint on %s", c.addr)
+
+	// The gateway is not opened, so the user of the bot is looked up over the REST API
+	user, err := c.session.User("@me")
+	if err != nil {
+		c.logger.Error("Failed to get the bot user: %v", err)
+		return err
+	}
+	c.session.State.User = user
+
+	c.server = &http.Server{Addr: c.addr, Handler: c}
+	go func() {
+		if err := c.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			c.logger.Error("Failed to serve interactions endpoint: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops serving the endpoint after the requests being served are done
+func (c *HTTPDiscordClient) Stop() error {
+	c.logger.Info("Stopping HTTP interactions endpoint")
+	if c.server == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return c.server.Shutdown(ctx)
+}
+
+// OnInteraction calls the handler with the interactions posted to the endpoint
+func (c *HTTPDiscordClient) OnInteraction(handler func(s domain.Session, i *domain.InteractionCreate)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, handler)
+}
+
+// OnMessage ignores the handler, since the messages are not delivered over HTTP
+func (c *HTTPDiscordClient) OnMessage(handler func(s domain.Session, m *domain.MessageCreate)) {
+	c.logger.Error("Ignoring message handler not supported by the HTTP interactions endpoint")
+}
+
+// verify checks the signature of the request made with the private key of the
+// application, and that the request was signed just now
+func (c *HTTPDiscordClient) verify(r *http.Request, body []byte) bool {
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	timestamp := r.Header.Get("X-Signature-Timestamp")
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := c.now().Sub(time.Unix(signedAt, 0)); skew > maxTimestampSkew || skew < -maxTimestampSkew {
+		c.logger.Info("Rejected interaction signed at %s", timestamp)
+		return false
+	}
+
+	return ed25519.Verify(c.publicKey, append([]byte(timestamp), body...), signature)
+}
+
+func (c *HTTPDiscordClient) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	// Discord checks that the requests with the invalid signature are rejected
+	if !c.verify(r, body) {
+		c.logger.Info("Rejected interaction with invalid signature")
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	interaction := &discordgo.InteractionCreate{}
+	if err := json.Unmarshal(body, interaction); err != nil {
+		c.logger.Error("Failed to parse interaction: %v", err)
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	// Answer the PING sent to check the endpoint
+	if interaction.Type == discordgo.InteractionPing {
+		c.logger.Info("Answering PING")
+		writeJSON(w, &discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong})
+		return
+	}
+
+	c.logger.Info("Received interaction over HTTP: ID=%s, Type=%d", interaction.ID, interaction.Type)
+
+	pending := &pendingInteraction{
+		responses: make(chan *capturedResponse, 1),
+		written:   make(chan struct{}),
+	}
+	c.mu.Lock()
+	c.pending[interaction.Token] = pending
+	handlers := c.handlers
+	c.mu.Unlock()
+
+	// The handlers run as they do for the gateway events, and send the initial response to the callback endpoint
+	go func() {
+		for _, handler := range handlers {
+			c.handleInteraction(c.session, interaction, handler)
+		}
+	}()
+
+	response, ok := c.await(interaction.Token, pending)
+	if !ok {
+		// Nobody acknowledged the interaction, so it fails instead of waiting for a response which never comes
+		c.logger.Error("No response to interaction %s in %s", interaction.ID, c.responseTimeout)
+		http.Error(w, "no response from the handler", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", response.contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(response.body); err != nil {
+		c.logger.Error("Failed to write response: %v", err)
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	c.forget(interaction.Token)
+	close(pending.written)
+}
+
+// await waits for the initial response of the handler, and returns false when
+// nothing started sending it in time, after which the interaction is forgotten
+func (c *HTTPDiscordClient) await(token string, pending *pendingInteraction) (*capturedResponse, bool) {
+	select {
+	case response := <-pending.responses:
+		return response, true
+	case <-time.After(c.responseTimeout):
+	}
+
+	c.mu.Lock()
+	claimed := pending.claimed
+	if !claimed {
+		delete(c.pending, token)
+	}
+	c.mu.Unlock()
+
+	// The handler is already sending the response, which is on its way
+	if claimed {
+		return <-pending.responses, true
+	}
+	return nil, false
+}
+
+// forget removes the interaction once its initial response is written
+func (c *HTTPDiscordClient) forget(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, token)
+}
+
+// claim marks the initial response of the interaction as being sent, and returns
+// false when the interaction is not received over HTTP or no longer waits for it.
+// The error is returned when the initial response was already sent.
+func (c *HTTPDiscordClient) claim(token string) (*pendingInteraction, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok {
+		return nil, false, nil
+	}
+	if pending.claimed {
+		return nil, true, errAlreadyResponded
+	}
+	pending.claimed = true
+	return pending, true, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// callbackTransport sends the requests of the REST API, except the initial
+// responses to the interactions received over HTTP, which are handed to the
+// HTTP response instead
+type callbackTransport struct {
+	client *HTTPDiscordClient
+	next   http.RoundTripper
+}
+
+// callbackToken returns the token of the interaction when the request is its initial response
+func callbackToken(r *http.Request) (string, bool) {
+	if r.Method != http.MethodPost {
+		return "", false
+	}
+
+	// The path is /api/v9/interactions/{id}/{token}/callback
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 6 || parts[2] != "interactions" || parts[5] != "callback" {
+		return "", false
+	}
+	return parts[4], true
+}
+
+func (t *callbackTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	token, ok := callbackToken(r)
+	if !ok {
+		return t.next.RoundTrip(r)
+	}
+	pending, ok, err := t.client.claim(token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return t.next.RoundTrip(r)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hand the response to the endpoint, and wait until it is sent so that the follow-ups come after it
+	pending.responses <- &capturedResponse{contentType: r.Header.Get("Content-Type"), body: body}
+	<-pending.written
+
+	return emptyResponse(r, http.StatusNoContent), nil
+}
+
+func emptyResponse(r *http.Request, status int) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    r,
+	}
+}
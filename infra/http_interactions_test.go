@@ -0,0 +1,283 @@
+package infra
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+// stubTransport records the requests sent to the REST API instead of sending them
+type stubTransport struct {
+	requests []*http.Request
+	bodies   []string
+}
+
+func (t *stubTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+	}
+	t.requests = append(t.requests, r)
+	t.bodies = append(t.bodies, string(body))
+
+	// The REST API returns the message for the edits and the follow-ups
+	response := emptyResponse(r, http.StatusOK)
+	response.Body = io.NopCloser(strings.NewReader("{}"))
+	return response, nil
+}
+
+func newTestHTTPClient(t *testing.T, ctrl *gomock.Controller) (*HTTPDiscordClient, ed25519.PrivateKey, *stubTransport) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	client, err := NewHTTPDiscordClient("token", hex.EncodeToString(publicKey), ":0", mockLogger)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	stub := &stubTransport{}
+	client.session.Client.Transport.(*callbackTransport).next = stub
+	client.now = func() time.Time { return time.Unix(signedAt, 0) }
+
+	return client, privateKey, stub
+}
+
+// signedAt is the time the test requests are signed at
+const signedAt = 1700000000
+
+// signedRequest makes the request signed as Discord does
+func signedRequest(privateKey ed25519.PrivateKey, body string) *http.Request {
+	timestamp := strconv.Itoa(signedAt)
+	signature := ed25519.Sign(privateKey, []byte(timestamp+body))
+
+	r := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(body))
+	r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+	r.Header.Set("X-Signature-Timestamp", timestamp)
+	return r
+}
+
+func TestHTTPDiscordClient_InvalidSignature(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, _, _ := newTestHTTPClient(t, ctrl)
+
+	// The request signed with the other key is rejected
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	w := httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(otherKey, `{"type":1}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", w.Code)
+	}
+
+	// The request without the signature is rejected as well
+	w = httptest.NewRecorder()
+	client.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(`{"type":1}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", w.Code)
+	}
+}
+
+func TestHTTPDiscordClient_Ping(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, privateKey, _ := newTestHTTPClient(t, ctrl)
+
+	w := httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(privateKey, `{"id":"1","type":1}`))
+
+	var response discordgo.InteractionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Type != discordgo.InteractionResponsePong {
+		t.Errorf("Expected PONG, got %s", w.Body.String())
+	}
+}
+
+func TestHTTPDiscordClient_Respond(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, privateKey, stub := newTestHTTPClient(t, ctrl)
+
+	// The handler responds through the session as it does for the gateway events
+	client.OnInteraction(func(s domain.Session, i *domain.InteractionCreate) {
+		if i.Data.Name != "ping" {
+			t.Errorf("Expected the ping command, got %s", i.Data.Name)
+		}
+		if err := s.InteractionRespond(i, &domain.InteractionResponse{
+			Type: int(domain.InteractionResponseChannelMessageWithSource),
+			Data: &domain.InteractionResponseData{Content: "Pong!"},
+		}); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(privateKey, `{"id":"10","application_id":"20","type":2,"token":"abc","data":{"id":"30","name":"ping","type":1}}`))
+
+	// The initial response is sent back as the HTTP response, not to the REST API
+	var response discordgo.InteractionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response %s: %v", w.Body.String(), err)
+	}
+	if response.Type != discordgo.InteractionResponseChannelMessageWithSource || response.Data.Content != "Pong!" {
+		t.Errorf("Unexpected response: %s", w.Body.String())
+	}
+	if len(stub.requests) != 0 {
+		t.Errorf("Expected no request to the REST API, got %d", len(stub.requests))
+	}
+	if _, ok := client.pending["abc"]; ok {
+		t.Errorf("Expected the interaction to be forgotten once responded")
+	}
+}
+
+func TestHTTPDiscordClient_StaleTimestamp(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, privateKey, _ := newTestHTTPClient(t, ctrl)
+
+	// The request signed a minute ago is rejected, so that it can not be replayed
+	client.now = func() time.Time { return time.Unix(signedAt, 0).Add(time.Minute) }
+	w := httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(privateKey, `{"type":1}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %d", w.Code)
+	}
+
+	// The request signed a moment ago is accepted
+	client.now = func() time.Time { return time.Unix(signedAt, 0).Add(time.Second) }
+	w = httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(privateKey, `{"type":1}`))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+}
+
+func TestHTTPDiscordClient_NoResponse(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, privateKey, stub := newTestHTTPClient(t, ctrl)
+	client.SetResponseTimeout(10 * time.Millisecond)
+
+	// The handler responds only after the endpoint gave up on the interaction
+	release := make(chan struct{})
+	done := make(chan struct{})
+	client.OnInteraction(func(s domain.Session, i *domain.InteractionCreate) {
+		defer close(done)
+		<-release
+		if err := s.InteractionRespond(i, &domain.InteractionResponse{
+			Type: int(domain.InteractionResponseChannelMessageWithSource),
+			Data: &domain.InteractionResponseData{Content: "遅れました"},
+		}); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(privateKey, `{"id":"10","application_id":"20","type":2,"token":"abc","data":{"id":"30","name":"q","type":1}}`))
+
+	// The interaction fails instead of being deferred without anybody to resolve it
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503, got %d", w.Code)
+	}
+	if _, ok := client.pending["abc"]; ok {
+		t.Errorf("Expected the interaction to be forgotten")
+	}
+
+	close(release)
+	<-done
+
+	// The late response goes to the REST API as it is, which tells the handler it is too late
+	if len(stub.requests) != 1 || !strings.HasSuffix(stub.requests[0].URL.Path, "/callback") {
+		t.Errorf("Expected the late response to be sent to the REST API, got %d requests", len(stub.requests))
+	}
+}
+
+func TestHTTPDiscordClient_RespondTwice(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, _, stub := newTestHTTPClient(t, ctrl)
+
+	// The interaction waits for its initial response as the endpoint does
+	pending := &pendingInteraction{
+		responses: make(chan *capturedResponse, 1),
+		written:   make(chan struct{}),
+	}
+	client.pending["abc"] = pending
+
+	// The transport is called directly, since discordgo sends the requests to the same endpoint one by one
+	transport := client.session.Client.Transport.(*callbackTransport)
+	callback := func(body string) (*http.Response, error) {
+		r := httptest.NewRequest(http.MethodPost, discordgo.EndpointInteractionResponse("10", "abc"), strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return transport.RoundTrip(r)
+	}
+
+	// The first response is handed to the endpoint, and waits until it is written
+	first := make(chan error)
+	go func() {
+		_, err := callback(`{"type":5}`)
+		first <- err
+	}()
+	response := <-pending.responses
+
+	// The second response fails instead of being dropped
+	if _, err := callback(`{"type":4,"data":{"content":"二度目"}}`); !errors.Is(err, errAlreadyResponded) {
+		t.Errorf("Expected the second response to fail, got %v", err)
+	}
+
+	close(pending.written)
+	if err := <-first; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if string(response.body) != `{"type":5}` {
+		t.Errorf("Expected the deferred response, got %s", response.body)
+	}
+	if len(stub.requests) != 0 {
+		t.Errorf("Expected no request to the REST API, got %d", len(stub.requests))
+	}
+}
+
+func TestHTTPDiscordClient_TooLarge(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, privateKey, _ := newTestHTTPClient(t, ctrl)
+
+	w := httptest.NewRecorder()
+	client.ServeHTTP(w, signedRequest(privateKey, strings.Repeat("a", maxRequestBody+1)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+}
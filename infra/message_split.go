@@ -0,0 +1,131 @@
+package infra
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the number of characters Discord allows in the content of a message
+const MaxMessageLength = 2000
+
+// DefaultAttachmentLimit is the length of the content sent as a file instead of several messages
+const DefaultAttachmentLimit = 4 * MaxMessageLength
+
+// attachmentName is the name of the file the long content is attached as
+const attachmentName = "message.txt"
+
+// markerRoom is the room kept in each message to close the code blocks and the spoilers
+const markerRoom = 16
+
+// maxCodeLanguage is the longest language of a code block carried over to the next message
+const maxCodeLanguage = 8
+
+// splitMessage splits the content into the messages within the limit.
+// The content is split on the paragraphs, the lines and the sentences in this
+// order, including the Japanese punctuation. The code blocks and the spoilers
+// split across the messages are closed and opened again in the next message.
+func splitMessage(content string, limit int) []string {
+	if utf8.RuneCountInString(content) <= limit {
+		return []string{content}
+	}
+
+	var chunks []string
+	reopen := ""
+	rest := content
+	for {
+		rest = reopen + rest
+		runes := []rune(rest)
+		if len(runes) <= limit {
+			return append(chunks, rest)
+		}
+
+		cut := splitPoint(runes, len([]rune(reopen)), limit-markerRoom)
+		chunk := strings.TrimRight(string(runes[:cut]), "\n")
+
+		var closing string
+		closing, reopen = openMarkers(chunk)
+		chunks = append(chunks, chunk+closing)
+
+		rest = strings.TrimLeft(string(runes[cut:]), "\n")
+		if rest == "" {
+			return chunks
+		}
+	}
+}
+
+// splitPoint finds where to split the runes within the budget. The point is
+// searched in the latter half of the budget so that the messages are not too short.
+func splitPoint(runes []rune, min int, budget int) int {
+	window := string(runes[:budget])
+	half := len(string(runes[:budget/2]))
+
+	separators := [][]string{
+		{"\n\n"},
+		{"\n"},
+		{"。", "！", "？", "!", "?", ". "},
+		{"、", "，", ", ", " ", "　"},
+	}
+	for _, candidates := range separators {
+		best := -1
+		for _, separator := range candidates {
+			if idx := strings.LastIndex(window, separator); idx >= half && idx+len(separator) > best {
+				best = idx + len(separator)
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		// Keep the closing brackets with the sentence
+		point := utf8.RuneCountInString(window[:best])
+		for point < budget && strings.ContainsRune("」』）)", runes[point]) {
+			point++
+		}
+		if point > min {
+			return point
+		}
+	}
+
+	return budget
+}
+
+// openMarkers returns the markers closing the code block and the spoiler left
+// open in the chunk, and the markers opening them again in the next chunk
+func openMarkers(chunk string) (string, string) {
+	inCode := false
+	language := ""
+	inSpoiler := false
+
+	for idx := 0; idx < len(chunk); {
+		switch {
+		case strings.HasPrefix(chunk[idx:], "```"):
+			inCode = !inCode
+			idx += len("```")
+			if inCode {
+				language, _, _ = strings.Cut(chunk[idx:], "\n")
+				if len(language) > maxCodeLanguage || strings.ContainsAny(language, " `") {
+					language = ""
+				}
+			}
+		case !inCode && strings.HasPrefix(chunk[idx:], "||"):
+			inSpoiler = !inSpoiler
+			idx += len("||")
+		default:
+			idx++
+		}
+	}
+
+	var closing, reopen string
+	if inCode {
+		closing += "\n```"
+	}
+	if inSpoiler {
+		closing += "||"
+		reopen += "||"
+	}
+	if inCode {
+		reopen += "```" + language + "\n"
+	}
+
+	return closing, reopen
+}
@@ -0,0 +1,85 @@
+package infra
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitMessage_Short(t *testing.T) {
+	chunks := splitMessage("はい", MaxMessageLength)
+	if len(chunks) != 1 || chunks[0] != "はい" {
+		t.Errorf("Expected the content as is, got %q", chunks)
+	}
+}
+
+func TestSplitMessage_Paragraphs(t *testing.T) {
+	first := strings.Repeat("あ", 1500)
+	second := strings.Repeat("い", 1500)
+
+	chunks := splitMessage(first+"\n\n"+second, MaxMessageLength)
+	if len(chunks) != 2 || chunks[0] != first || chunks[1] != second {
+		t.Errorf("Expected the content to be split on the paragraph, got %d chunks", len(chunks))
+	}
+}
+
+func TestSplitMessage_JapaneseSentences(t *testing.T) {
+	sentence := strings.Repeat("あ", 99) + "。"
+	content := strings.Repeat(sentence, 50)
+
+	chunks := splitMessage(content, MaxMessageLength)
+	if len(chunks) < 3 {
+		t.Fatalf("Expected at least 3 chunks, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if length := utf8.RuneCountInString(chunk); length > MaxMessageLength {
+			t.Errorf("Expected the chunk within %d characters, got %d", MaxMessageLength, length)
+		}
+		if !strings.HasSuffix(chunk, "。") {
+			t.Errorf("Expected the chunk to end with a sentence, got %q", chunk[len(chunk)-9:])
+		}
+	}
+	if strings.Join(chunks, "") != content {
+		t.Errorf("Expected the chunks to make up the content")
+	}
+}
+
+func TestSplitMessage_Markers(t *testing.T) {
+	code := "```go\n" + strings.Repeat("fmt.Println(\"umi\")\n", 150) + "```"
+	spoiler := "||" + strings.Repeat("男性は遭難した時に亀のスープを飲んだ。", 150) + "||"
+
+	for _, content := range []string{code, spoiler} {
+		chunks := splitMessage(content, MaxMessageLength)
+		if len(chunks) < 2 {
+			t.Fatalf("Expected the content to be split, got %d chunks", len(chunks))
+		}
+		for _, chunk := range chunks {
+			if length := utf8.RuneCountInString(chunk); length > MaxMessageLength {
+				t.Errorf("Expected the chunk within %d characters, got %d", MaxMessageLength, length)
+			}
+			// Every chunk opens and closes its own code block and spoiler
+			if closing, _ := openMarkers(chunk); closing != "" {
+				t.Errorf("Expected every marker to be closed in the chunk, got %q left open", closing)
+			}
+		}
+	}
+
+	chunks := splitMessage(code, MaxMessageLength)
+	if !strings.HasPrefix(chunks[1], "```go\n") {
+		t.Errorf("Expected the code block to be opened again with its language, got %q", chunks[1][:10])
+	}
+}
+
+func TestSplitMessage_NoSeparator(t *testing.T) {
+	content := strings.Repeat("a", 4500)
+
+	chunks := splitMessage(content, MaxMessageLength)
+	if strings.Join(chunks, "") != content {
+		t.Errorf("Expected the chunks to make up the content")
+	}
+	for _, chunk := range chunks {
+		if length := utf8.RuneCountInString(chunk); length > MaxMessageLength {
+			t.Errorf("Expected the chunk within %d characters, got %d", MaxMessageLength, length)
+		}
+	}
+}
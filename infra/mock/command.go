@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/gong023/umi/domain (interfaces: CommandHandler)
+// Source: github.com/gong023/umi/domain (interfaces: CommandHandler,Command,MessageHandler)
 //
 // Generated by this command:
 //
-//	mockgen -destination=infra/mock/command.go -package=mock github.com/gong023/umi/domain CommandHandler
+//	mockgen -destination=infra/mock/command.go -package=mock github.com/gong023/umi/domain CommandHandler,Command,MessageHandler
 //
 // Package mock is a generated GoMock package.
 package mock
@@ -49,3 +49,87 @@ func (mr *MockCommandHandlerMockRecorder) Handle(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockCommandHandler)(nil).Handle), arg0, arg1)
 }
+
+// MockCommand is a mock of Command interface.
+type MockCommand struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandMockRecorder
+}
+
+// MockCommandMockRecorder is the mock recorder for MockCommand.
+type MockCommandMockRecorder struct {
+	mock *MockCommand
+}
+
+// NewMockCommand creates a new mock instance.
+func NewMockCommand(ctrl *gomock.Controller) *MockCommand {
+	mock := &MockCommand{ctrl: ctrl}
+	mock.recorder = &MockCommandMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommand) EXPECT() *MockCommandMockRecorder {
+	return m.recorder
+}
+
+// Definition mocks base method.
+func (m *MockCommand) Definition() *domain.ApplicationCommand {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Definition")
+	ret0, _ := ret[0].(*domain.ApplicationCommand)
+	return ret0
+}
+
+// Definition indicates an expected call of Definition.
+func (mr *MockCommandMockRecorder) Definition() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Definition", reflect.TypeOf((*MockCommand)(nil).Definition))
+}
+
+// Handle mocks base method.
+func (m *MockCommand) Handle(arg0 domain.Session, arg1 *domain.InteractionCreate) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Handle", arg0, arg1)
+}
+
+// Handle indicates an expected call of Handle.
+func (mr *MockCommandMockRecorder) Handle(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockCommand)(nil).Handle), arg0, arg1)
+}
+
+// MockMessageHandler is a mock of MessageHandler interface.
+type MockMessageHandler struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageHandlerMockRecorder
+}
+
+// MockMessageHandlerMockRecorder is the mock recorder for MockMessageHandler.
+type MockMessageHandlerMockRecorder struct {
+	mock *MockMessageHandler
+}
+
+// NewMockMessageHandler creates a new mock instance.
+func NewMockMessageHandler(ctrl *gomock.Controller) *MockMessageHandler {
+	mock := &MockMessageHandler{ctrl: ctrl}
+	mock.recorder = &MockMessageHandlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageHandler) EXPECT() *MockMessageHandlerMockRecorder {
+	return m.recorder
+}
+
+// HandleMessage mocks base method.
+func (m *MockMessageHandler) HandleMessage(arg0 domain.Session, arg1 *domain.MessageCreate) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "HandleMessage", arg0, arg1)
+}
+
+// HandleMessage indicates an expected call of HandleMessage.
+func (mr *MockMessageHandlerMockRecorder) HandleMessage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleMessage", reflect.TypeOf((*MockMessageHandler)(nil).HandleMessage), arg0, arg1)
+}
@@ -38,46 +38,71 @@ func (m *MockDiscordClient) EXPECT() *MockDiscordClientMockRecorder {
 	return m.recorder
 }
 
-// DeleteCommands mocks base method.
-func (m *MockDiscordClient) DeleteCommands() error {
+// Commands mocks base method.
+func (m *MockDiscordClient) Commands(arg0 string) ([]*domain.ApplicationCommand, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCommands")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Commands", arg0)
+	ret0, _ := ret[0].([]*domain.ApplicationCommand)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Commands indicates an expected call of Commands.
+func (mr *MockDiscordClientMockRecorder) Commands(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commands", reflect.TypeOf((*MockDiscordClient)(nil).Commands), arg0)
+}
+
+// OnInteraction mocks base method.
+func (m *MockDiscordClient) OnInteraction(arg0 func(domain.Session, *domain.InteractionCreate)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnInteraction", arg0)
+}
+
+// OnInteraction indicates an expected call of OnInteraction.
+func (mr *MockDiscordClientMockRecorder) OnInteraction(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnInteraction", reflect.TypeOf((*MockDiscordClient)(nil).OnInteraction), arg0)
+}
+
+// OnMessage mocks base method.
+func (m *MockDiscordClient) OnMessage(arg0 func(domain.Session, *domain.MessageCreate)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnMessage", arg0)
 }
 
-// DeleteCommands indicates an expected call of DeleteCommands.
-func (mr *MockDiscordClientMockRecorder) DeleteCommands() *gomock.Call {
+// OnMessage indicates an expected call of OnMessage.
+func (mr *MockDiscordClientMockRecorder) OnMessage(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommands", reflect.TypeOf((*MockDiscordClient)(nil).DeleteCommands))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnMessage", reflect.TypeOf((*MockDiscordClient)(nil).OnMessage), arg0)
 }
 
-// RegisterCommands mocks base method.
-func (m *MockDiscordClient) RegisterCommands(arg0 []*domain.ApplicationCommand) error {
+// OverwriteCommands mocks base method.
+func (m *MockDiscordClient) OverwriteCommands(arg0 string, arg1 []*domain.ApplicationCommand) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegisterCommands", arg0)
+	ret := m.ctrl.Call(m, "OverwriteCommands", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RegisterCommands indicates an expected call of RegisterCommands.
-func (mr *MockDiscordClientMockRecorder) RegisterCommands(arg0 any) *gomock.Call {
+// OverwriteCommands indicates an expected call of OverwriteCommands.
+func (mr *MockDiscordClientMockRecorder) OverwriteCommands(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterCommands", reflect.TypeOf((*MockDiscordClient)(nil).RegisterCommands), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OverwriteCommands", reflect.TypeOf((*MockDiscordClient)(nil).OverwriteCommands), arg0, arg1)
 }
 
-// RegisterHandler mocks base method.
-func (m *MockDiscordClient) RegisterHandler(arg0 any) func() {
+// Session mocks base method.
+func (m *MockDiscordClient) Session() domain.Session {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegisterHandler", arg0)
-	ret0, _ := ret[0].(func())
+	ret := m.ctrl.Call(m, "Session")
+	ret0, _ := ret[0].(domain.Session)
 	return ret0
 }
 
-// RegisterHandler indicates an expected call of RegisterHandler.
-func (mr *MockDiscordClientMockRecorder) RegisterHandler(arg0 any) *gomock.Call {
+// Session indicates an expected call of Session.
+func (mr *MockDiscordClientMockRecorder) Session() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterHandler", reflect.TypeOf((*MockDiscordClient)(nil).RegisterHandler), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Session", reflect.TypeOf((*MockDiscordClient)(nil).Session))
 }
 
 // Start mocks base method.
@@ -37,6 +37,21 @@ func (m *MockFileSystem) EXPECT() *MockFileSystemMockRecorder {
 	return m.recorder
 }
 
+// AppendFile mocks base method.
+func (m *MockFileSystem) AppendFile(arg0 string, arg1 []byte, arg2 int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendFile", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AppendFile indicates an expected call of AppendFile.
+func (mr *MockFileSystemMockRecorder) AppendFile(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendFile", reflect.TypeOf((*MockFileSystem)(nil).AppendFile), arg0, arg1, arg2)
+}
+
 // FileExists mocks base method.
 func (m *MockFileSystem) FileExists(arg0 string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -99,6 +114,20 @@ func (mr *MockFileSystemMockRecorder) RemoveFile(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFile", reflect.TypeOf((*MockFileSystem)(nil).RemoveFile), arg0)
 }
 
+// RenameFile mocks base method.
+func (m *MockFileSystem) RenameFile(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameFile", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameFile indicates an expected call of RenameFile.
+func (mr *MockFileSystemMockRecorder) RenameFile(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameFile", reflect.TypeOf((*MockFileSystem)(nil).RenameFile), arg0, arg1)
+}
+
 // WriteFile mocks base method.
 func (m *MockFileSystem) WriteFile(arg0 string, arg1 []byte, arg2 int) error {
 	m.ctrl.T.Helper()
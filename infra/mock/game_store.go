@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/gong023/umi/domain (interfaces: GameStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=infra/mock/game_store.go -package=mock github.com/gong023/umi/domain GameStore
+//
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	reflect "reflect"
+
+	domain "github.com/gong023/umi/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockGameStore is a mock of GameStore interface.
+type MockGameStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockGameStoreMockRecorder
+}
+
+// MockGameStoreMockRecorder is the mock recorder for MockGameStore.
+type MockGameStoreMockRecorder struct {
+	mock *MockGameStore
+}
+
+// NewMockGameStore creates a new mock instance.
+func NewMockGameStore(ctrl *gomock.Controller) *MockGameStore {
+	mock := &MockGameStore{ctrl: ctrl}
+	mock.recorder = &MockGameStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGameStore) EXPECT() *MockGameStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockGameStore) Delete() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockGameStoreMockRecorder) Delete() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockGameStore)(nil).Delete))
+}
+
+// Load mocks base method.
+func (m *MockGameStore) Load() (*domain.Game, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load")
+	ret0, _ := ret[0].(*domain.Game)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockGameStoreMockRecorder) Load() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockGameStore)(nil).Load))
+}
+
+// Save mocks base method.
+func (m *MockGameStore) Save(arg0 *domain.Game) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockGameStoreMockRecorder) Save(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockGameStore)(nil).Save), arg0)
+}
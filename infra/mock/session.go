@@ -38,6 +38,20 @@ func (m *MockSession) EXPECT() *MockSessionMockRecorder {
 	return m.recorder
 }
 
+// ArchiveThread mocks base method.
+func (m *MockSession) ArchiveThread(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveThread", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveThread indicates an expected call of ArchiveThread.
+func (mr *MockSessionMockRecorder) ArchiveThread(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveThread", reflect.TypeOf((*MockSession)(nil).ArchiveThread), arg0)
+}
+
 // FollowupMessage mocks base method.
 func (m *MockSession) FollowupMessage(arg0 *domain.InteractionCreate, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -52,6 +66,20 @@ func (mr *MockSessionMockRecorder) FollowupMessage(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowupMessage", reflect.TypeOf((*MockSession)(nil).FollowupMessage), arg0, arg1)
 }
 
+// FollowupResponse mocks base method.
+func (m *MockSession) FollowupResponse(arg0 *domain.InteractionCreate, arg1 *domain.InteractionResponseData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FollowupResponse", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FollowupResponse indicates an expected call of FollowupResponse.
+func (mr *MockSessionMockRecorder) FollowupResponse(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FollowupResponse", reflect.TypeOf((*MockSession)(nil).FollowupResponse), arg0, arg1)
+}
+
 // InteractionRespond mocks base method.
 func (m *MockSession) InteractionRespond(arg0 *domain.InteractionCreate, arg1 *domain.InteractionResponse) error {
 	m.ctrl.T.Helper()
@@ -65,3 +93,74 @@ func (mr *MockSessionMockRecorder) InteractionRespond(arg0, arg1 any) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InteractionRespond", reflect.TypeOf((*MockSession)(nil).InteractionRespond), arg0, arg1)
 }
+
+// InteractionResponseDelete mocks base method.
+func (m *MockSession) InteractionResponseDelete(arg0 *domain.InteractionCreate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InteractionResponseDelete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InteractionResponseDelete indicates an expected call of InteractionResponseDelete.
+func (mr *MockSessionMockRecorder) InteractionResponseDelete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InteractionResponseDelete", reflect.TypeOf((*MockSession)(nil).InteractionResponseDelete), arg0)
+}
+
+// InteractionResponseEdit mocks base method.
+func (m *MockSession) InteractionResponseEdit(arg0 *domain.InteractionCreate, arg1 *domain.InteractionResponseData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InteractionResponseEdit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InteractionResponseEdit indicates an expected call of InteractionResponseEdit.
+func (mr *MockSessionMockRecorder) InteractionResponseEdit(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InteractionResponseEdit", reflect.TypeOf((*MockSession)(nil).InteractionResponseEdit), arg0, arg1)
+}
+
+// ReplyMessage mocks base method.
+func (m *MockSession) ReplyMessage(arg0, arg1 string, arg2 *domain.InteractionResponseData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplyMessage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplyMessage indicates an expected call of ReplyMessage.
+func (mr *MockSessionMockRecorder) ReplyMessage(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplyMessage", reflect.TypeOf((*MockSession)(nil).ReplyMessage), arg0, arg1, arg2)
+}
+
+// SendMessage mocks base method.
+func (m *MockSession) SendMessage(arg0 string, arg1 *domain.InteractionResponseData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMessage indicates an expected call of SendMessage.
+func (mr *MockSessionMockRecorder) SendMessage(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockSession)(nil).SendMessage), arg0, arg1)
+}
+
+// StartThread mocks base method.
+func (m *MockSession) StartThread(arg0, arg1 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartThread", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartThread indicates an expected call of StartThread.
+func (mr *MockSessionMockRecorder) StartThread(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartThread", reflect.TypeOf((*MockSession)(nil).StartThread), arg0, arg1)
+}
@@ -0,0 +1,145 @@
+package infra
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/gong023/umi/domain"
+)
+
+// fakeQuiz is the canned response to the JSON requests. It satisfies both of the
+// generated quiz and the key point judgment, so that a game can be played through.
+const fakeQuiz = `{
+  "puzzle": "男はレストランでウミガメのスープを注文し、一口飲んだ後に自殺した。なぜか？",
+  "solution": "男はかつて遭難した際、仲間にウミガメのスープだと言われて人肉を食べて生き延びた。本物の味を知り、真実に気づいた。",
+  "key_points": ["男は遭難したことがある", "以前飲んだのはウミガメのスープではなかった"],
+  "covered": [1, 2],
+  "comment": "(fake) 正解です。"
+}`
+
+// fakeReply is the canned response to the other requests, which /q reads as "はい"
+const fakeReply = "はい。\n補足: (fake)"
+
+// FakeOpenAIClient returns the canned responses without calling the API, which is
+// enough to try the flow of the commands locally
+type FakeOpenAIClient struct {
+	logger domain.Logger
+}
+
+func NewFakeOpenAIClient(logger domain.Logger) *FakeOpenAIClient {
+	return &FakeOpenAIClient{logger: logger}
+}
+
+func (c *FakeOpenAIClient) CreateChatCompletion(req *domain.ChatCompletionRequest) (*domain.ChatCompletionResponse, error) {
+	c.logger.Info("Returning the fake chat completion")
+
+	content := fakeReply
+	if req.ResponseFormat != nil && req.ResponseFormat.Type == "json_object" {
+		content = fakeQuiz
+	}
+
+	resp := &domain.ChatCompletionResponse{ID: "fake", Object: "chat.completion"}
+	n := req.N
+	if n < 1 {
+		n = 1
+	}
+	resp.Choices = make([]struct {
+		Index        int                `json:"index"`
+		Message      domain.ChatMessage `json:"message"`
+		FinishReason string             `json:"finish_reason"`
+	}, n)
+	for index := range resp.Choices {
+		resp.Choices[index].Index = index
+		resp.Choices[index].Message = domain.ChatMessage{Role: "assistant", Content: content}
+		resp.Choices[index].FinishReason = "stop"
+	}
+
+	return resp, nil
+}
+
+// exchange is a line of the recorded file
+type exchange struct {
+	Request  *domain.ChatCompletionRequest  `json:"request"`
+	Response *domain.ChatCompletionResponse `json:"response"`
+}
+
+// RecordingOpenAIClient writes the requests and the responses of the other client
+// as JSON lines, which ReplayOpenAIClient reads later
+type RecordingOpenAIClient struct {
+	next   domain.OpenAIClient
+	w      io.Writer
+	logger domain.Logger
+	mu     sync.Mutex
+}
+
+func NewRecordingOpenAIClient(next domain.OpenAIClient, w io.Writer, logger domain.Logger) *RecordingOpenAIClient {
+	return &RecordingOpenAIClient{next: next, w: w, logger: logger}
+}
+
+func (c *RecordingOpenAIClient) CreateChatCompletion(req *domain.ChatCompletionRequest) (*domain.ChatCompletionResponse, error) {
+	resp, err := c.next.CreateChatCompletion(req)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(&exchange{Request: req, Response: resp})
+	if err != nil {
+		c.logger.Error("Failed to marshal the exchange: %v", err)
+		return resp, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		c.logger.Error("Failed to record the exchange: %v", err)
+	}
+
+	return resp, nil
+}
+
+// ReplayOpenAIClient returns the recorded responses in the order they were recorded
+type ReplayOpenAIClient struct {
+	responses []*domain.ChatCompletionResponse
+	logger    domain.Logger
+	mu        sync.Mutex
+}
+
+func NewReplayOpenAIClient(r io.Reader, logger domain.Logger) (*ReplayOpenAIClient, error) {
+	c := &ReplayOpenAIClient{logger: logger}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var recorded exchange
+		if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the recorded exchange: %w", err)
+		}
+		c.responses = append(c.responses, recorded.Response)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the recorded exchanges: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *ReplayOpenAIClient) CreateChatCompletion(req *domain.ChatCompletionRequest) (*domain.ChatCompletionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.responses) == 0 {
+		c.logger.Error("No recorded response left to replay")
+		return nil, fmt.Errorf("no recorded response left to replay")
+	}
+
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	c.logger.Info("Replaying the recorded chat completion, %d left", len(c.responses))
+	return resp, nil
+}
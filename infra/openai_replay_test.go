@@ -0,0 +1,77 @@
+package infra
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFakeOpenAIClient(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	client := NewFakeOpenAIClient(mockLogger)
+
+	// The JSON requests get the quiz, as many as the samples asked
+	resp, err := client.CreateChatCompletion(&domain.ChatCompletionRequest{
+		N:              3,
+		ResponseFormat: &domain.ResponseFormat{Type: "json_object"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Choices) != 3 || resp.Choices[2].Message.Content != fakeQuiz {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	resp, err = client.CreateChatCompletion(&domain.ChatCompletionRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != fakeReply {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+func TestReplayOpenAIClient(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Record the responses of the fake client
+	recorded := &bytes.Buffer{}
+	recorder := NewRecordingOpenAIClient(NewFakeOpenAIClient(mockLogger), recorded, mockLogger)
+	recorder.CreateChatCompletion(&domain.ChatCompletionRequest{ResponseFormat: &domain.ResponseFormat{Type: "json_object"}})
+	recorder.CreateChatCompletion(&domain.ChatCompletionRequest{})
+
+	// The responses are replayed in the recorded order
+	replayer, err := NewReplayOpenAIClient(recorded, mockLogger)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, expected := range []string{fakeQuiz, fakeReply} {
+		resp, err := replayer.CreateChatCompletion(&domain.ChatCompletionRequest{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.Choices[0].Message.Content != expected {
+			t.Errorf("Expected %q, got %q", expected, resp.Choices[0].Message.Content)
+		}
+	}
+
+	// It fails once the recorded responses run out
+	if _, err := replayer.CreateChatCompletion(&domain.ChatCompletionRequest{}); err == nil {
+		t.Errorf("Expected an error after the recorded responses")
+	}
+}
@@ -0,0 +1,443 @@
+package infra
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gong023/umi/domain"
+)
+
+const (
+	// terminalChannelID is the channel the games in the terminal are played in
+	terminalChannelID = "terminal"
+
+	// terminalUserID is the user playing in the terminal
+	terminalUserID = "terminal-user"
+
+	// terminalPrompt is shown while waiting for the input
+	terminalPrompt = "> "
+)
+
+// TerminalSession shows the responses to the interactions in the terminal
+type TerminalSession struct {
+	out io.Writer
+
+	// modal is the modal opened by the last response, which the next line is submitted to
+	modal *domain.InteractionResponseData
+
+	threads int
+
+	mu sync.Mutex
+}
+
+func NewTerminalSession(out io.Writer) *TerminalSession {
+	return &TerminalSession{out: out}
+}
+
+// print writes the message with its embeds and its buttons, which can be pressed by typing their command
+func (s *TerminalSession) print(prefix string, data *domain.InteractionResponseData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	if data.Flags&domain.MessageFlagsEphemeral != 0 {
+		prefix += "(only you) "
+	}
+	if data.Content != "" {
+		b.WriteString(prefix + data.Content + "\n")
+	}
+	for _, embed := range data.Embeds {
+		if embed.Title != "" {
+			b.WriteString("== " + embed.Title + " ==\n")
+		}
+		if embed.Description != "" {
+			b.WriteString(embed.Description + "\n")
+		}
+		for _, field := range embed.Fields {
+			b.WriteString(fmt.Sprintf("- %s: %s\n", field.Name, field.Value))
+		}
+		if embed.Footer != nil {
+			b.WriteString(embed.Footer.Text + "\n")
+		}
+	}
+	for _, row := range data.Components {
+		var buttons []string
+		for _, component := range row.Components {
+			if button, ok := component.(*domain.Button); ok && !button.Disabled {
+				buttons = append(buttons, fmt.Sprintf("[%s] !%s", button.Label, button.CustomID))
+			}
+		}
+		if len(buttons) > 0 {
+			b.WriteString(strings.Join(buttons, "  ") + "\n")
+		}
+	}
+
+	fmt.Fprint(s.out, b.String())
+}
+
+func (s *TerminalSession) printLine(format string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.out, format+"\n", args...)
+}
+
+func (s *TerminalSession) printPrompt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.out, terminalPrompt)
+}
+
+func (s *TerminalSession) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+	switch domain.InteractionResponseType(r.Type) {
+	case domain.InteractionResponseDeferredChannelMessageWithSource:
+		s.printLine("(thinking…)")
+	case domain.InteractionResponseModal:
+		s.mu.Lock()
+		s.modal = r.Data
+		s.mu.Unlock()
+		s.printLine("(%s) type the text to submit:", r.Data.Title)
+	default:
+		if r.Data != nil {
+			s.print("", r.Data)
+		}
+	}
+	return nil
+}
+
+func (s *TerminalSession) InteractionResponseEdit(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	s.print("", data)
+	return nil
+}
+
+func (s *TerminalSession) FollowupMessage(i *domain.InteractionCreate, content string) error {
+	s.print("", &domain.InteractionResponseData{Content: content})
+	return nil
+}
+
+func (s *TerminalSession) FollowupResponse(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	s.print("", data)
+	return nil
+}
+
+func (s *TerminalSession) InteractionResponseDelete(i *domain.InteractionCreate) error {
+	s.printLine("(response deleted)")
+	return nil
+}
+
+func (s *TerminalSession) StartThread(channelID string, name string) (string, error) {
+	s.mu.Lock()
+	s.threads++
+	threadID := fmt.Sprintf("%s-thread-%d", terminalChannelID, s.threads)
+	s.mu.Unlock()
+
+	s.printLine("(thread started: %s)", name)
+	return threadID, nil
+}
+
+func (s *TerminalSession) SendMessage(channelID string, data *domain.InteractionResponseData) error {
+	s.print("#"+channelID+": ", data)
+	return nil
+}
+
+func (s *TerminalSession) ArchiveThread(threadID string) error {
+	s.printLine("(thread archived: %s)", threadID)
+	return nil
+}
+
+func (s *TerminalSession) ReplyMessage(channelID string, messageID string, data *domain.InteractionResponseData) error {
+	s.print("↳ ", data)
+	return nil
+}
+
+// takeModal returns the modal waiting for the input, and forgets it
+func (s *TerminalSession) takeModal() *domain.InteractionResponseData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	modal := s.modal
+	s.modal = nil
+	return modal
+}
+
+// TerminalClient reads the commands from the terminal instead of Discord, so
+// that the games can be played and tested locally with the same handlers. The
+// lines starting with / are the commands, the lines starting with ! press the
+// buttons by their custom ID, and the other lines are posted as the messages.
+type TerminalClient struct {
+	in      io.Reader
+	session *TerminalSession
+	logger  domain.Logger
+	locale  string
+
+	interactionHandlers []func(s domain.Session, i *domain.InteractionCreate)
+	messageHandlers     []func(s domain.Session, m *domain.MessageCreate)
+
+	// commands are the definitions registered by the bot, which the options are parsed with
+	commands map[string]*domain.ApplicationCommand
+
+	nextID int
+}
+
+func NewTerminalClient(in io.Reader, out io.Writer, logger domain.Logger) *TerminalClient {
+	return &TerminalClient{
+		in:       in,
+		session:  NewTerminalSession(out),
+		logger:   logger,
+		locale:   "ja",
+		commands: make(map[string]*domain.ApplicationCommand),
+	}
+}
+
+// SetLocale sets the locale of the user, such as "ja" or "en-US"
+func (c *TerminalClient) SetLocale(locale string) {
+	c.locale = locale
+}
+
+func (c *TerminalClient) Start() error {
+	return nil
+}
+
+func (c *TerminalClient) Stop() error {
+	return nil
+}
+
+// Session returns the terminal, which prints the messages sent outside of the interactions
+func (c *TerminalClient) Session() domain.Session {
+	return c.session
+}
+
+func (c *TerminalClient) OnInteraction(handler func(s domain.Session, i *domain.InteractionCreate)) {
+	c.interactionHandlers = append(c.interactionHandlers, handler)
+}
+
+func (c *TerminalClient) OnMessage(handler func(s domain.Session, m *domain.MessageCreate)) {
+	c.messageHandlers = append(c.messageHandlers, handler)
+}
+
+func (c *TerminalClient) Commands(guildID string) ([]*domain.ApplicationCommand, error) {
+	commands := make([]*domain.ApplicationCommand, 0, len(c.commands))
+	for _, command := range c.commands {
+		commands = append(commands, command)
+	}
+	return commands, nil
+}
+
+func (c *TerminalClient) OverwriteCommands(guildID string, commands []*domain.ApplicationCommand) error {
+	c.commands = make(map[string]*domain.ApplicationCommand, len(commands))
+	for _, command := range commands {
+		c.commands[command.Name] = command
+	}
+	return nil
+}
+
+// Run reads the lines until the end of the input or /exit
+func (c *TerminalClient) Run() error {
+	scanner := bufio.NewScanner(c.in)
+	c.session.printLine("Type /help for the commands, !<custom ID> to press a button, and /exit to quit.")
+
+	for {
+		c.session.printPrompt()
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "/exit" {
+			return nil
+		}
+		if line == "" {
+			continue
+		}
+
+		if err := c.handleLine(line); err != nil {
+			c.session.printLine("error: %v", err)
+		}
+	}
+}
+
+// handleLine converts the line to an interaction or a message, and calls the handlers with it
+func (c *TerminalClient) handleLine(line string) error {
+	c.logger.Info("Read the line from the terminal: %s", line)
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+
+	// The line after a modal is opened is submitted to it
+	if modal := c.session.takeModal(); modal != nil {
+		c.interact(c.newInteraction(id, domain.InteractionModalSubmit, func(i *domain.InteractionCreate) {
+			i.Modal = &domain.ModalSubmitInteractionData{
+				CustomID: modal.CustomID,
+				Values:   modalValues(modal, line),
+			}
+		}))
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(line, "/"):
+		interaction, err := c.ParseCommand(id, line)
+		if err != nil {
+			return err
+		}
+		c.interact(interaction)
+	case strings.HasPrefix(line, "!"):
+		c.interact(c.newInteraction(id, domain.InteractionMessageComponent, func(i *domain.InteractionCreate) {
+			i.Component = &domain.MessageComponentInteractionData{CustomID: strings.TrimPrefix(line, "!")}
+		}))
+	default:
+		message := &domain.MessageCreate{
+			ID:        id,
+			ChannelID: terminalChannelID,
+			Content:   line,
+			AuthorID:  terminalUserID,
+		}
+		for _, handler := range c.messageHandlers {
+			handler(c.session, message)
+		}
+	}
+
+	return nil
+}
+
+func (c *TerminalClient) interact(i *domain.InteractionCreate) {
+	for _, handler := range c.interactionHandlers {
+		handler(c.session, i)
+	}
+}
+
+func (c *TerminalClient) newInteraction(id string, interactionType domain.InteractionType, fill func(i *domain.InteractionCreate)) *domain.InteractionCreate {
+	i := &domain.InteractionCreate{
+		ID:        id,
+		Type:      int(interactionType),
+		ChannelID: terminalChannelID,
+		User:      &domain.User{ID: terminalUserID, Username: "terminal"},
+		Member:    &domain.Member{},
+		Locale:    c.locale,
+	}
+	fill(i)
+	return i
+}
+
+// ParseCommand parses the command line such as "/q 男性は船に乗っていましたか？" or
+// "/clue private:true". The words in the form name:value are the options of the
+// command, and the rest of the line is the first string option.
+func (c *TerminalClient) ParseCommand(id string, line string) (*domain.InteractionCreate, error) {
+	fields := strings.Fields(strings.TrimPrefix(line, "/"))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no command")
+	}
+
+	command, ok := c.findCommand(fields[0])
+	if !ok {
+		return nil, fmt.Errorf("unknown command: /%s", fields[0])
+	}
+
+	data := &domain.ApplicationCommandInteractionData{Name: command.Name}
+	var rest []string
+	for _, field := range fields[1:] {
+		option, value, ok := parseOption(command, field)
+		if !ok {
+			rest = append(rest, field)
+			continue
+		}
+		data.Options = append(data.Options, &domain.ApplicationCommandInteractionDataOption{
+			Type:  option.Type,
+			Name:  option.Name,
+			Value: value,
+		})
+	}
+
+	// The rest of the line is the text of the command, such as the question
+	if len(rest) > 0 {
+		text := strings.Join(rest, " ")
+		option := firstStringOption(command)
+		if option == nil {
+			return nil, fmt.Errorf("/%s takes no text: %s", command.Name, text)
+		}
+		data.Options = append(data.Options, &domain.ApplicationCommandInteractionDataOption{
+			Type:  option.Type,
+			Name:  option.Name,
+			Value: text,
+		})
+	}
+
+	return c.newInteraction(id, domain.InteractionApplicationCommand, func(i *domain.InteractionCreate) {
+		i.Data = data
+	}), nil
+}
+
+// findCommand finds the command by its name or by any of its localized names, as the Discord clients do
+func (c *TerminalClient) findCommand(name string) (*domain.ApplicationCommand, bool) {
+	if command, ok := c.commands[name]; ok {
+		return command, true
+	}
+	for _, command := range c.commands {
+		if hasLocalizedName(command.NameLocalizations, name) {
+			return command, true
+		}
+	}
+	return nil, false
+}
+
+func hasLocalizedName(localizations map[string]string, name string) bool {
+	for _, localized := range localizations {
+		if localized == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOption parses the word in the form name:value into the value of the option,
+// typed as in the JSON from Discord
+func parseOption(command *domain.ApplicationCommand, field string) (*domain.ApplicationCommandOption, interface{}, bool) {
+	name, raw, ok := strings.Cut(field, ":")
+	if !ok {
+		return nil, nil, false
+	}
+
+	for _, option := range command.Options {
+		if option.Name != name && !hasLocalizedName(option.NameLocalizations, name) {
+			continue
+		}
+
+		switch option.Type {
+		case domain.ApplicationCommandOptionBoolean:
+			value, err := strconv.ParseBool(raw)
+			return option, value, err == nil
+		case domain.ApplicationCommandOptionInteger, domain.ApplicationCommandOptionNumber:
+			value, err := strconv.ParseFloat(raw, 64)
+			return option, value, err == nil
+		default:
+			return option, raw, true
+		}
+	}
+
+	return nil, nil, false
+}
+
+func firstStringOption(command *domain.ApplicationCommand) *domain.ApplicationCommandOption {
+	for _, option := range command.Options {
+		if option.Type == domain.ApplicationCommandOptionString {
+			return option
+		}
+	}
+	return nil
+}
+
+// modalValues fills the text inputs of the modal with the line
+func modalValues(modal *domain.InteractionResponseData, line string) map[string]string {
+	values := make(map[string]string)
+	for _, row := range modal.Components {
+		for _, component := range row.Components {
+			if input, ok := component.(*domain.TextInput); ok {
+				values[input.CustomID] = line
+			}
+		}
+	}
+	return values
+}
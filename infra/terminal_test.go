@@ -0,0 +1,127 @@
+package infra
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestTerminalClient(ctrl *gomock.Controller, input string) (*TerminalClient, *bytes.Buffer) {
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	out := &bytes.Buffer{}
+	client := NewTerminalClient(strings.NewReader(input), out, mockLogger)
+	client.OverwriteCommands("", []*domain.ApplicationCommand{
+		{
+			Name: "q",
+			Options: []*domain.ApplicationCommandOption{
+				{Type: domain.ApplicationCommandOptionString, Name: "message"},
+			},
+		},
+		{
+			Name:              "clue",
+			NameLocalizations: map[string]string{"ja": "ヒント"},
+			Options: []*domain.ApplicationCommandOption{
+				{Type: domain.ApplicationCommandOptionBoolean, Name: "private", NameLocalizations: map[string]string{"ja": "非公開"}},
+			},
+		},
+	})
+	return client, out
+}
+
+func TestTerminalClient_ParseCommand(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, _ := newTestTerminalClient(ctrl, "")
+
+	// The rest of the line is the first string option
+	i, err := client.ParseCommand("1", "/q 男は 船に乗っていましたか？")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if i.Data.Name != "q" || i.Data.Option("message").StringValue() != "男は 船に乗っていましたか？" {
+		t.Errorf("Unexpected command: %+v", i.Data)
+	}
+
+	// The options are typed by their definitions
+	i, err = client.ParseCommand("2", "/clue private:true")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !i.Data.Option("private").BoolValue() {
+		t.Errorf("Expected the private option, got %+v", i.Data)
+	}
+
+	// The localized names are accepted as well
+	i, err = client.ParseCommand("3", "/ヒント 非公開:true")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if i.Data.Name != "clue" || !i.Data.Option("private").BoolValue() {
+		t.Errorf("Expected the private clue, got %+v", i.Data)
+	}
+
+	// The command takes no text, nor unknown commands
+	if _, err := client.ParseCommand("3", "/clue please"); err == nil {
+		t.Errorf("Expected an error for the text of /clue")
+	}
+	if _, err := client.ParseCommand("4", "/unknown"); err == nil {
+		t.Errorf("Expected an error for the unknown command")
+	}
+}
+
+func TestTerminalClient_Run(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client, out := newTestTerminalClient(ctrl, "/q はい？\n!umi:answer\n長い回答\n/exit\n/q ignored\n")
+
+	// The button opens the modal, which the next line is submitted to
+	var received []*domain.InteractionCreate
+	client.OnInteraction(func(s domain.Session, i *domain.InteractionCreate) {
+		received = append(received, i)
+		if i.Component != nil {
+			s.InteractionRespond(i, &domain.InteractionResponse{
+				Type: int(domain.InteractionResponseModal),
+				Data: &domain.InteractionResponseData{
+					CustomID: "umi:answer:modal",
+					Title:    "回答する",
+					Components: []*domain.ActionsRow{
+						{Components: []domain.MessageComponent{&domain.TextInput{CustomID: "message"}}},
+					},
+				},
+			})
+			return
+		}
+		s.InteractionRespond(i, &domain.InteractionResponse{
+			Type: int(domain.InteractionResponseChannelMessageWithSource),
+			Data: &domain.InteractionResponseData{Content: "はい", Flags: domain.MessageFlagsEphemeral},
+		})
+	})
+
+	if err := client.Run(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The lines after /exit are not read
+	if len(received) != 3 {
+		t.Fatalf("Expected 3 interactions, got %d", len(received))
+	}
+	if received[1].Component.CustomID != "umi:answer" {
+		t.Errorf("Expected the button, got %+v", received[1].Component)
+	}
+	if modal := received[2].Modal; modal == nil || modal.CustomID != "umi:answer:modal" || modal.Values["message"] != "長い回答" {
+		t.Errorf("Expected the modal submission, got %+v", received[2])
+	}
+	if !strings.Contains(out.String(), "(only you) はい") {
+		t.Errorf("Expected the ephemeral response, got %s", out.String())
+	}
+}
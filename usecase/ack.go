@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gong023/umi/domain"
+)
+
+// defaultAckTimeout is how long the bot waits for the handler to respond before
+// acknowledging the interaction itself, within the 3 seconds Discord waits for it
+const defaultAckTimeout = 2 * time.Second
+
+// ackSession acknowledges the interaction on behalf of the handler when the handler
+// does not respond in time, such as while the interaction waits in the queue of the
+// dispatcher, or while the handler calls the OpenAI API before deferring. The HTTP
+// endpoint relies on it as well, since it only waits for the initial response. The commands and the modals are deferred as a message, and the buttons
+// as an update of their message. The responses of the handler after that are sent as
+// the edits of the deferred response, or as the follow-ups when they can not replace it.
+type ackSession struct {
+	domain.Session
+	interaction *domain.InteractionCreate
+	logger      domain.Logger
+	timer       *time.Timer
+
+	// responded is true once the initial response is sent, by the handler or by the bot
+	responded bool
+
+	// acked is true when the bot deferred the response itself
+	acked bool
+
+	// followup sends the edits of the handler as the follow-ups, since the deferred response is not theirs to replace
+	followup bool
+
+	// ephemeral shows the follow-ups only to the user
+	ephemeral bool
+
+	// placeholder is true while the message deferred by the bot is still shown
+	placeholder bool
+
+	mu sync.Mutex
+}
+
+// newAckSession acknowledges the interaction once the timeout passes without a response
+func newAckSession(session domain.Session, interaction *domain.InteractionCreate, timeout time.Duration, logger domain.Logger) *ackSession {
+	a := &ackSession{
+		Session:     session,
+		interaction: interaction,
+		logger:      logger,
+	}
+	a.timer = time.AfterFunc(timeout, a.ack)
+	return a
+}
+
+// isComponent returns true when the interaction is a button, whose message the deferred update keeps
+func (a *ackSession) isComponent() bool {
+	return domain.InteractionType(a.interaction.Type) == domain.InteractionMessageComponent
+}
+
+// ack defers the response unless the handler already responded
+func (a *ackSession) ack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.responded {
+		return
+	}
+	a.responded = true
+	a.acked = true
+
+	deferred := domain.InteractionResponseDeferredChannelMessageWithSource
+	if a.isComponent() {
+		deferred = domain.InteractionResponseDeferredMessageUpdate
+	} else {
+		a.placeholder = true
+	}
+
+	a.logger.Info("Acknowledging interaction %s before its handler responds", a.interaction.ID)
+	if err := a.Session.InteractionRespond(a.interaction, &domain.InteractionResponse{Type: int(deferred)}); err != nil {
+		a.logger.Error("Failed to acknowledge interaction: %v", err)
+	}
+}
+
+// done stops acknowledging the interaction once its handler returned
+func (a *ackSession) done() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.timer.Stop()
+	a.responded = true
+}
+
+func (a *ackSession) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+	a.mu.Lock()
+	if !a.acked {
+		a.responded = true
+		a.timer.Stop()
+		a.mu.Unlock()
+		return a.Session.InteractionRespond(i, r)
+	}
+	defer a.mu.Unlock()
+
+	ephemeral := r.Data != nil && r.Data.Flags&domain.MessageFlagsEphemeral != 0
+
+	switch domain.InteractionResponseType(r.Type) {
+	case domain.InteractionResponseDeferredChannelMessageWithSource:
+		// The message of the button, or the public placeholder, is not the response the handler edits later
+		if a.isComponent() || ephemeral {
+			a.followup = true
+			a.ephemeral = ephemeral
+		}
+		return nil
+	case domain.InteractionResponseDeferredMessageUpdate:
+		return nil
+	case domain.InteractionResponseChannelMessageWithSource:
+		if a.isComponent() || ephemeral {
+			return a.follow(r.Data)
+		}
+		return a.Session.InteractionResponseEdit(i, r.Data)
+	case domain.InteractionResponseUpdateMessage:
+		return a.Session.InteractionResponseEdit(i, r.Data)
+	default:
+		// A modal can be opened only as the initial response, so the user is asked to try again
+		a.logger.Error("Response type %d can not be sent after the interaction was acknowledged", r.Type)
+		return a.follow(&domain.InteractionResponseData{
+			Content: localizerFor(i).T(msgBusy),
+			Flags:   domain.MessageFlagsEphemeral,
+		})
+	}
+}
+
+func (a *ackSession) InteractionResponseEdit(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.followup {
+		return a.Session.InteractionResponseEdit(i, data)
+	}
+
+	followup := *data
+	if a.ephemeral {
+		followup.Flags |= domain.MessageFlagsEphemeral
+	}
+	return a.follow(&followup)
+}
+
+// follow sends the response as a follow-up, and removes the placeholder it replaces
+func (a *ackSession) follow(data *domain.InteractionResponseData) error {
+	if a.placeholder {
+		a.placeholder = false
+		if err := a.Session.InteractionResponseDelete(a.interaction); err != nil {
+			a.logger.Error("Failed to delete the deferred response: %v", err)
+		}
+	}
+	return a.Session.FollowupResponse(a.interaction, data)
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAckSession_RespondedInTime(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	i := &domain.InteractionCreate{ID: "interaction-id", Type: int(domain.InteractionApplicationCommand)}
+	ack := newAckSession(mockSession, i, time.Hour, mockLogger)
+
+	// The response of the handler is sent as it is, and the bot does not acknowledge it again
+	response := &domain.InteractionResponse{Type: int(domain.InteractionResponseModal), Data: &domain.InteractionResponseData{Title: "回答"}}
+	mockSession.EXPECT().InteractionRespond(i, response).Return(nil)
+
+	if err := ack.InteractionRespond(i, response); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	ack.ack()
+}
+
+func TestAckSession_Command(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	i := &domain.InteractionCreate{ID: "interaction-id", Type: int(domain.InteractionApplicationCommand)}
+	ack := newAckSession(mockSession, i, time.Hour, mockLogger)
+
+	// The bot defers the command, and the deferral and the edit of the handler follow it
+	mockSession.EXPECT().InteractionRespond(i, &domain.InteractionResponse{Type: int(domain.InteractionResponseDeferredChannelMessageWithSource)}).Return(nil)
+	mockSession.EXPECT().InteractionResponseEdit(i, &domain.InteractionResponseData{Content: "はい"}).Return(nil)
+
+	ack.ack()
+	if err := deferResponse(ack, i); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := editResponse(ack, i, "はい"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestAckSession_Command_Ephemeral(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	i := &domain.InteractionCreate{ID: "interaction-id", Type: int(domain.InteractionApplicationCommand)}
+	ack := newAckSession(mockSession, i, time.Hour, mockLogger)
+
+	// The public placeholder is replaced by the ephemeral follow-up
+	denied := &domain.InteractionResponseData{Content: "denied", Flags: domain.MessageFlagsEphemeral}
+	gomock.InOrder(
+		mockSession.EXPECT().InteractionRespond(i, gomock.Any()).Return(nil),
+		mockSession.EXPECT().InteractionResponseDelete(i).Return(nil),
+		mockSession.EXPECT().FollowupResponse(i, denied).Return(nil),
+	)
+
+	ack.ack()
+	if err := ack.InteractionRespond(i, &domain.InteractionResponse{Type: int(domain.InteractionResponseChannelMessageWithSource), Data: denied}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestAckSession_Component(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	i := &domain.InteractionCreate{ID: "interaction-id", Type: int(domain.InteractionMessageComponent)}
+	ack := newAckSession(mockSession, i, time.Hour, mockLogger)
+
+	// The button is deferred without touching the game message, which the private clue does not replace
+	mockSession.EXPECT().InteractionRespond(i, &domain.InteractionResponse{Type: int(domain.InteractionResponseDeferredMessageUpdate)}).Return(nil)
+	mockSession.EXPECT().FollowupResponse(i, &domain.InteractionResponseData{Content: "ヒント", Flags: domain.MessageFlagsEphemeral}).Return(nil)
+
+	ack.ack()
+	if err := deferEphemeralResponse(ack, i); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := editResponse(ack, i, "ヒント"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// The update of the message still edits it
+	updated := &domain.InteractionResponseData{Content: "updated"}
+	mockSession.EXPECT().InteractionResponseEdit(i, updated).Return(nil)
+	if err := ack.InteractionRespond(i, &domain.InteractionResponse{Type: int(domain.InteractionResponseUpdateMessage), Data: updated}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestAckSession_Modal(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	i := &domain.InteractionCreate{ID: "interaction-id", Type: int(domain.InteractionMessageComponent)}
+	ack := newAckSession(mockSession, i, time.Hour, mockLogger)
+
+	// The modal can not be opened anymore, so the user is asked to try again
+	mockSession.EXPECT().InteractionRespond(i, gomock.Any()).Return(nil)
+	mockSession.EXPECT().FollowupResponse(i, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+			if data.Flags&domain.MessageFlagsEphemeral == 0 || data.Content != localizerFor(i).T(msgBusy) {
+				t.Errorf("Unexpected follow-up: %+v", data)
+			}
+			return nil
+		})
+
+	ack.ack()
+	if err := ack.InteractionRespond(i, answerModal(localizerFor(i))); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestAckSession_Timeout(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// The interaction waiting longer than the timeout is acknowledged by the bot
+	acked := make(chan struct{})
+	i := &domain.InteractionCreate{ID: "interaction-id", Type: int(domain.InteractionApplicationCommand)}
+	mockSession.EXPECT().InteractionRespond(i, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			close(acked)
+			return nil
+		})
+
+	ack := newAckSession(mockSession, i, 10*time.Millisecond, mockLogger)
+	select {
+	case <-acked:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the interaction to be acknowledged")
+	}
+	ack.done()
+}
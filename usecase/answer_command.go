@@ -1,89 +1,90 @@
 package usecase
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/bwmarrin/discordgo"
 	"github.com/gong023/umi/domain"
 )
 
 type AnswerCommandHandler struct {
-	openaiClient domain.OpenAIClient
-	logger       domain.Logger
+	openaiClient      domain.OpenAIClient
+	fileSystem        domain.FileSystem
+	gameStore         domain.GameStore
+	leakGuard         *LeakGuard
+	logger            domain.Logger
+	judgeSamples      int
+	judgeAgreement    float64
+	keyPointThreshold float64
 }
 
-func NewAnswerCommandHandler(openaiClient domain.OpenAIClient, logger domain.Logger) *AnswerCommandHandler {
+func NewAnswerCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, gameStore domain.GameStore, leakGuard *LeakGuard, logger domain.Logger) *AnswerCommandHandler {
 	return &AnswerCommandHandler{
-		openaiClient: openaiClient,
-		logger:       logger,
+		openaiClient:      openaiClient,
+		fileSystem:        fileSystem,
+		gameStore:         gameStore,
+		leakGuard:         leakGuard,
+		logger:            logger,
+		judgeSamples:      1,
+		keyPointThreshold: defaultKeyPointThreshold,
 	}
 }
 
+// SetKeyPointThreshold sets the share of the key points an answer has to cover to be accepted
+func (h *AnswerCommandHandler) SetKeyPointThreshold(threshold float64) {
+	h.keyPointThreshold = threshold
+}
+
+// SetJudgeVoting enables the self-consistency judging mode. The answer is judged
+// by the given number of samples, and the verdict is accepted only when the share
+// of agreeing samples reaches agreement. Otherwise the answer is judged as close.
+func (h *AnswerCommandHandler) SetJudgeVoting(samples int, agreement float64) {
+	if samples < 1 {
+		samples = 1
+	}
+	h.judgeSamples = samples
+	h.judgeAgreement = agreement
+}
+
+func (h *AnswerCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "answer",
+		Options: []*domain.ApplicationCommandOption{
+			{
+				// The answer is optional, a modal is opened to write a long answer without it
+				Type:      domain.ApplicationCommandOptionString,
+				Name:      "message",
+				Required:  false,
+				MaxLength: 1000,
+			},
+		},
+	})
+}
+
 func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling answer command")
-
-	// Extract the message from the command options
-	var message string
+	l := localizerFor(i)
 
 	// Log the entire interaction data for debugging
 	h.logger.Info("Interaction data: %+v", i)
 
-	// Check if the interaction data contains the message directly
-	if i.Original != nil {
-		originalInteraction, ok := i.Original.(*discordgo.InteractionCreate)
-		if ok {
-			h.logger.Info("Original interaction: %+v", originalInteraction)
-
-			// Try to get the command data
-			if originalInteraction.Type == discordgo.InteractionApplicationCommand {
-				data := originalInteraction.ApplicationCommandData()
-				h.logger.Info("Application command data: %+v", data)
-
-				// Check if there are options
-				if len(data.Options) > 0 {
-					h.logger.Info("Command options found: %d options", len(data.Options))
-					for idx, opt := range data.Options {
-						h.logger.Info("Option %d: Name=%s, Value=%v", idx, opt.Name, opt.Value)
-					}
-
-					// Try to extract the message from the options
-					for _, opt := range data.Options {
-						if opt.Name == "message" {
-							if val, ok := opt.Value.(string); ok {
-								message = val
-								h.logger.Info("Extracted answer from user: %s", message)
-							} else {
-								h.logger.Error("Failed to extract answer: Value is not a string: %T", opt.Value)
-							}
-							break
-						}
-					}
-				} else {
-					h.logger.Error("No options found in ApplicationCommandData")
-				}
-			} else {
-				h.logger.Error("Interaction is not an ApplicationCommand: %d", originalInteraction.Type)
-			}
-		} else {
-			h.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
-		}
-	} else if i.Data != nil && len(i.Data.Options) > 0 {
-		h.logger.Info("Command options found in domain model: %d options", len(i.Data.Options))
-		for idx, opt := range i.Data.Options {
-			h.logger.Info("Option %d: Name=%s, Value=%v", idx, opt.Name, opt.Value)
-		}
+	// Extract the answer from the modal, or from the command options
+	var message string
+	if i.Modal != nil {
+		message = i.Modal.Values[answerInputCustomID]
+		h.logger.Info("Extracted answer from modal: %s", message)
+	} else {
+		message = stringOption(i, "message")
+		h.logger.Info("Extracted answer from user: %s", message)
+	}
 
-		if val, ok := i.Data.Options[0].Value.(string); ok {
-			message = val
-			h.logger.Info("Extracted answer from user: %s", message)
-		} else {
-			h.logger.Error("Failed to extract answer: Value is not a string: %T", i.Data.Options[0].Value)
+	// Open the modal to write the answer when it was not given, such as from the answer button
+	if message == "" && i.Modal == nil {
+		h.logger.Info("No message provided in answer command, opening the answer modal")
+		if err := s.InteractionRespond(i, answerModal(l)); err != nil {
+			h.logger.Error("Failed to respond to interaction: %v", err)
 		}
-	} else {
-		h.logger.Error("No command options found: Data=%v", i.Data)
+		return
 	}
 
 	if message == "" {
@@ -92,7 +93,7 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		response := &domain.InteractionResponse{
 			Type: int(domain.InteractionResponseChannelMessageWithSource),
 			Data: &domain.InteractionResponseData{
-				Content: "回答を入力してください。例: `/answer 男性は亀のスープを飲んだことがあり、妻が亀のスープを作ったことを思い出して自殺した`",
+				Content: l.T(msgAnswerEmpty),
 			},
 		}
 		if err := s.InteractionRespond(i, response); err != nil {
@@ -101,32 +102,32 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		return
 	}
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "回答を判定しています...",
-		},
-	}
-
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
 
 	// Check if a quiz exists
-	contextPath := filepath.Join("memo", "context.txt")
+	contextPath := h.fileSystem.JoinPath("memo", "context.txt")
 	quizExists := false
 	var contextContent []byte
 	var conversationHistory []string
 
 	// Check if the context file exists
-	if _, err := os.Stat(contextPath); err == nil {
+	exists, err := h.fileSystem.FileExists(contextPath)
+	if err != nil {
+		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+
+	if exists {
 		// Read the existing context file
-		contextContent, err = os.ReadFile(contextPath)
+		contextContent, err = h.fileSystem.ReadFile(contextPath)
 		if err != nil {
 			h.logger.Error("Failed to read context file: %v", err)
+			failResponse(s, i, h.logger)
 			return
 		}
 
@@ -141,11 +142,11 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	if !quizExists {
 		h.logger.Info("No quiz found")
 		// Send a response indicating that no quiz is available
-		followupMessage := "現在クイズが存在しません。`/create` コマンドで新しいクイズを作成してください。"
+		followupMessage := l.T(msgNoQuiz)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, followupMessage); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editResponse(s, i, followupMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		h.logger.Info("No quiz available, suggesting /create command: %s", followupMessage)
@@ -153,10 +154,11 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	}
 
 	// Read the prompt file
-	promptPath := filepath.Join("memo", "prompt", "onAnswer.txt")
-	promptContent, err := os.ReadFile(promptPath)
+	promptPath := h.fileSystem.JoinPath("memo", "prompt", "onAnswer.txt")
+	promptContent, err := h.fileSystem.ReadFile(promptPath)
 	if err != nil {
 		h.logger.Error("Failed to read prompt file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -168,6 +170,17 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		},
 	}
 
+	// Give the stored solution to the model so that it is consistent with the other commands
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+	if solution, ok := solutionMessage(game, false); ok {
+		messages = append(messages, solution)
+	}
+
 	// Process the conversation history and add it to the messages
 	if len(conversationHistory) > 0 {
 		// The first line is always the quiz (assistant's role)
@@ -241,43 +254,64 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		h.logger.Info("Message %d - Role: %s, Content: %s", i, msg.Role, msg.Content)
 	}
 
-	req := &domain.ChatCompletionRequest{
-		Model:       "chatgpt-4o-latest",
-		Messages:    messages,
-		Temperature: 0.7,
-	}
+	var result *judgeResult
+	if len(game.KeyPoints) > 0 {
+		// Judge the answer against each key point of the stored solution
+		keyPointPromptPath := h.fileSystem.JoinPath("memo", "prompt", "onAnswerKeyPoints.txt")
+		keyPointPrompt, err := h.fileSystem.ReadFile(keyPointPromptPath)
+		if err != nil {
+			h.logger.Error("Failed to read prompt file: %v", err)
+			failResponse(s, i, h.logger)
+			return
+		}
 
-	// Send the request to the OpenAI API
-	h.logger.Info("Sending request to OpenAI API")
-	resp, err := h.openaiClient.CreateChatCompletion(req)
-	if err != nil {
-		h.logger.Error("Failed to create chat completion: %v", err)
-		return
-	}
+		result, err = h.judgeKeyPoints(l, string(keyPointPrompt), game, conversationHistory[0], message)
+		if err != nil {
+			h.logger.Error("Failed to judge key points: %v", err)
+			failResponse(s, i, h.logger)
+			return
+		}
+	} else {
+		// Sample the judgments from the OpenAI API
+		req := &domain.ChatCompletionRequest{
+			Model:       "chatgpt-4o-latest",
+			Messages:    messages,
+			Temperature: 0.7,
+		}
+		judgments, err := h.sampleJudgments(req, h.judgeSamples)
+		if err != nil {
+			h.logger.Error("Failed to create chat completion: %v", err)
+			failResponse(s, i, h.logger)
+			return
+		}
 
-	// Extract the judgment from the response
-	if len(resp.Choices) == 0 {
-		h.logger.Error("No choices in response")
-		return
+		// Take a vote over the sampled judgments
+		result = tallyJudgments(l, judgments, h.judgeAgreement)
 	}
-
-	judgment := resp.Choices[0].Message.Content
+	judgment := result.Content
 	h.logger.Info("Received judgment: %s", judgment)
+	if result.Samples() > 1 {
+		h.logger.Info("Judgment vote: %s", result.Breakdown(l))
+	}
 
 	// Check if the answer is correct
-	isCorrect := !strings.Contains(judgment, "不正解")
+	isCorrect := result.Verdict == verdictCorrect
 
-	// Format the judgment
-	formattedJudgment := fmt.Sprintf("**回答**: %s\n\n**判定**: %s", message, strings.TrimSpace(judgment))
+	// Show the judgment as a card colour-coded by the verdict
+	embed := verdictEmbed(l, message, result)
 
 	if isCorrect {
 		// If the answer is correct, delete the context file
-		if err := os.Remove(contextPath); err != nil {
+		if err := h.fileSystem.RemoveFile(contextPath); err != nil {
 			h.logger.Error("Failed to delete context file: %v", err)
 			// Continue with the response even if we fail to delete the context file
 		} else {
 			h.logger.Info("Deleted context file because the answer was correct")
 		}
+
+		if err := h.gameStore.Delete(); err != nil {
+			h.logger.Error("Failed to delete game record: %v", err)
+		}
 	} else {
 		// If the answer is incorrect, append the judgment to the context file
 		// First, read the existing content
@@ -299,8 +333,13 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		// Add the assistant's judgment
 		updatedContent += judgment
 
+		// Record the vote breakdown when the answer was judged by several samples
+		if result.Samples() > 1 {
+			updatedContent += "\n" + result.Breakdown(l)
+		}
+
 		// Write the updated content back to the context file
-		if err := os.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
+		if err := h.fileSystem.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
 			h.logger.Error("Failed to update context file: %v", err)
 			// Continue with the response even if we fail to update the context file
 		} else {
@@ -309,9 +348,14 @@ func (h *AnswerCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	}
 
 	// Send the response with the judgment
-	if err := s.FollowupMessage(i, formattedJudgment); err != nil {
-		h.logger.Error("Failed to send follow-up message: %v", err)
+	if err := editEmbed(s, i, embed); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
+	}
+
+	// Close the thread of the game with the verdict when the quiz is solved
+	if isCorrect {
+		closeGameThread(s, i, game, &domain.InteractionResponseData{Embeds: []*domain.MessageEmbed{embed}}, h.logger)
 	}
 
-	h.logger.Info("Judgment created: %s", formattedJudgment)
+	h.logger.Info("Judgment created: %s: %s", embed.Title, embed.Description)
 }
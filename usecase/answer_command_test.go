@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/gong023/umi/domain"
@@ -70,8 +71,11 @@ func TestAnswerCommandHandler_Handle(t *testing.T) {
 
 	mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).Return(mockResponse, nil)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the answer command handler
-	handler := NewAnswerCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewAnswerCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, NewLeakGuard(nil, mockLogger), mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -135,17 +139,73 @@ func TestAnswerCommandHandler_Handle_NoMessage(t *testing.T) {
 		},
 	}
 
-	// Set up expectations for the session
-	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil)
+	// Without an answer, the modal to write it is opened
+	mockSession.EXPECT().InteractionRespond(interaction, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Type != int(domain.InteractionResponseModal) {
+				t.Errorf("Expected the answer modal, got type %d", r.Type)
+			}
+			if r.Data.CustomID != CustomIDAnswerModal {
+				t.Errorf("Expected the modal to be routed to %s, got %s", CustomIDAnswerModal, r.Data.CustomID)
+			}
+			return nil
+		},
+	)
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
 
 	// Create the answer command handler
-	handler := NewAnswerCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewAnswerCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, NewLeakGuard(nil, mockLogger), mockLogger)
 
 	// Handle the interaction
 	handler.Handle(mockSession, interaction)
+}
 
-	// No assertions needed as we're just testing that the handler doesn't panic
-	// and that the expected methods are called (which is verified by the mock expectations)
+func TestAnswerCommandHandler_Handle_EmptyModal(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock OpenAI client
+	mockOpenAIClient := mock.NewMockOpenAIClient(ctrl)
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create a mock interaction of the submitted modal without an answer
+	interaction := &domain.InteractionCreate{
+		ID:   "test-interaction-id",
+		Type: int(domain.InteractionModalSubmit),
+		Modal: &domain.ModalSubmitInteractionData{
+			CustomID: CustomIDAnswerModal,
+			Values:   map[string]string{answerInputCustomID: ""},
+		},
+	}
+
+	// The modal is not opened again, and the user is asked for the answer instead
+	mockSession.EXPECT().InteractionRespond(interaction, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Type != int(domain.InteractionResponseChannelMessageWithSource) {
+				t.Errorf("Expected a message, got type %d", r.Type)
+			}
+			return nil
+		},
+	)
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
+	// Create the answer command handler
+	handler := NewAnswerCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, NewLeakGuard(nil, mockLogger), mockLogger)
+
+	// Handle the interaction
+	handler.Handle(mockSession, interaction)
 }
 
 func TestAnswerCommandHandler_Handle_NoQuiz(t *testing.T) {
@@ -186,8 +246,11 @@ func TestAnswerCommandHandler_Handle_NoQuiz(t *testing.T) {
 	// First for the initial response, second for the follow-up response
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the answer command handler
-	handler := NewAnswerCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewAnswerCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, NewLeakGuard(nil, mockLogger), mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -217,3 +280,208 @@ func TestAnswerCommandHandler_Handle_NoQuiz(t *testing.T) {
 	// No assertions needed as we're just testing that the handler doesn't panic
 	// and that the expected methods are called (which is verified by the mock expectations)
 }
+
+func TestTallyJudgments_Majority(t *testing.T) {
+	judgments := []string{
+		"正解です。男性は過去に亀のスープを飲んだことがありました。",
+		"不正解です。",
+		"正解です。",
+	}
+
+	result := tallyJudgments(localizerFor(nil), judgments, 0.5)
+
+	if result.Verdict != verdictCorrect {
+		t.Errorf("Expected verdict to be %s, got %s", verdictCorrect, result.Verdict)
+	}
+	if result.Content != judgments[0] {
+		t.Errorf("Expected content to be the first correct judgment, got '%s'", result.Content)
+	}
+	if result.Correct != 2 || result.Incorrect != 1 {
+		t.Errorf("Expected 2 correct and 1 incorrect votes, got %d and %d", result.Correct, result.Incorrect)
+	}
+}
+
+func TestTallyJudgments_DisagreementIsClose(t *testing.T) {
+	judgments := []string{"正解です。", "不正解です。", "正解です。"}
+
+	// Two thirds agree, which is below the required agreement
+	result := tallyJudgments(localizerFor(nil), judgments, 0.8)
+
+	if result.Verdict != verdictClose {
+		t.Errorf("Expected verdict to be %s, got %s", verdictClose, result.Verdict)
+	}
+	if strings.Contains(result.Content, "正解です") {
+		t.Errorf("Expected the close judgment not to contain a sampled judgment, got '%s'", result.Content)
+	}
+}
+
+func TestTallyJudgments_Unanimous(t *testing.T) {
+	judgments := []string{"不正解です。", "不正解です。", "不正解です。"}
+
+	result := tallyJudgments(localizerFor(nil), judgments, 1.0)
+
+	if result.Verdict != verdictIncorrect {
+		t.Errorf("Expected verdict to be %s, got %s", verdictIncorrect, result.Verdict)
+	}
+	if result.Confidence != 1.0 {
+		t.Errorf("Expected confidence to be 1.0, got %f", result.Confidence)
+	}
+}
+
+func TestAnswerCommandHandler_SampleJudgments(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock OpenAI client
+	mockOpenAIClient := mock.NewMockOpenAIClient(ctrl)
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	newResponse := func(contents ...string) *domain.ChatCompletionResponse {
+		resp := &domain.ChatCompletionResponse{}
+		for idx, content := range contents {
+			resp.Choices = append(resp.Choices, struct {
+				Index        int                `json:"index"`
+				Message      domain.ChatMessage `json:"message"`
+				FinishReason string             `json:"finish_reason"`
+			}{
+				Index:        idx,
+				Message:      domain.ChatMessage{Role: "assistant", Content: content},
+				FinishReason: "stop",
+			})
+		}
+		return resp
+	}
+
+	// The first call returns fewer choices than requested, so the rest is requested again
+	gomock.InOrder(
+		mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).DoAndReturn(
+			func(req *domain.ChatCompletionRequest) (*domain.ChatCompletionResponse, error) {
+				if req.N != 3 {
+					t.Errorf("Expected n to be 3, got %d", req.N)
+				}
+				return newResponse("正解です。"), nil
+			}),
+		mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).DoAndReturn(
+			func(req *domain.ChatCompletionRequest) (*domain.ChatCompletionResponse, error) {
+				if req.N != 2 {
+					t.Errorf("Expected n to be 2, got %d", req.N)
+				}
+				return newResponse("不正解です。", "正解です。"), nil
+			}),
+	)
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
+	handler := NewAnswerCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, NewLeakGuard(nil, mockLogger), mockLogger)
+	handler.SetJudgeVoting(3, 0.5)
+
+	req := &domain.ChatCompletionRequest{
+		Model:    "chatgpt-4o-latest",
+		Messages: []domain.ChatMessage{{Role: "user", Content: "回答: test"}},
+	}
+	judgments, err := handler.sampleJudgments(req, handler.judgeSamples)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(judgments) != 3 {
+		t.Errorf("Expected 3 judgments, got %d", len(judgments))
+	}
+}
+
+func TestParseKeyPointJudgment(t *testing.T) {
+	judgment, err := parseKeyPointJudgment(`{"covered": [3, 1, 1, 7, 0], "comment": "良い線です"}`, 5)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The numbers out of range and the duplicates are dropped
+	if len(judgment.Covered) != 2 || judgment.Covered[0] != 1 || judgment.Covered[1] != 3 {
+		t.Errorf("Expected covered to be [1 3], got %v", judgment.Covered)
+	}
+	if judgment.Comment != "良い線です" {
+		t.Errorf("Unexpected comment: %s", judgment.Comment)
+	}
+}
+
+func TestTallyKeyPoints_Threshold(t *testing.T) {
+	keyPoints := []string{"男性は遭難した", "仲間の肉を食べた", "亀のスープだと騙された", "本物の亀のスープを飲んだ", "真実に気付いた"}
+
+	// Three of five points are below the threshold of 0.8
+	result := tallyKeyPoints(localizerFor(nil), nil, []*keyPointJudgment{{Covered: []int{1, 2, 4}, Comment: "あと一歩です"}}, keyPoints, 0.8, 0)
+
+	if result.Verdict != verdictIncorrect {
+		t.Errorf("Expected verdict to be %s, got %s", verdictIncorrect, result.Verdict)
+	}
+	if !strings.Contains(result.Content, "3/5 の要素が合っています") {
+		t.Errorf("Expected the progress to be shown, got '%s'", result.Content)
+	}
+	if !strings.Contains(result.Content, keyPoints[0]) || strings.Contains(result.Content, keyPoints[2]) {
+		t.Errorf("Expected only the covered key points to be shown, got '%s'", result.Content)
+	}
+
+	// Four of five points meet the threshold
+	result = tallyKeyPoints(localizerFor(nil), nil, []*keyPointJudgment{{Covered: []int{1, 2, 3, 4}}}, keyPoints, 0.8, 0)
+	if result.Verdict != verdictCorrect {
+		t.Errorf("Expected verdict to be %s, got %s", verdictCorrect, result.Verdict)
+	}
+}
+
+func TestTallyKeyPoints_LeakedComment(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// The blocked comment is logged
+	mockFileSystem := mock.NewMockFileSystem(ctrl)
+	mockFileSystem.EXPECT().JoinPath("memo", "blocked.log").Return("memo/blocked.log")
+	mockFileSystem.EXPECT().AppendFile("memo/blocked.log", gomock.Any(), 0644).Return(int64(0), nil)
+
+	keyPoints := []string{"男性は遭難した", "仲間の肉を食べた", "亀のスープだと騙された"}
+	judgments := []*keyPointJudgment{{Covered: []int{1}, Comment: "答えは亀のスープだと騙されたことです"}}
+
+	// The comment stating a key point not covered yet is dropped
+	result := tallyKeyPoints(localizerFor(nil), NewLeakGuard(mockFileSystem, mockLogger), judgments, keyPoints, 0.8, 0)
+	if strings.Contains(result.Content, judgments[0].Comment) {
+		t.Errorf("Expected the comment to be dropped, got '%s'", result.Content)
+	}
+
+	// The comment about the covered key points is kept
+	judgments = []*keyPointJudgment{{Covered: []int{1}, Comment: "遭難したことは合っています"}}
+	result = tallyKeyPoints(localizerFor(nil), NewLeakGuard(mockFileSystem, mockLogger), judgments, keyPoints, 0.8, 0)
+	if !strings.Contains(result.Content, judgments[0].Comment) {
+		t.Errorf("Expected the comment to be kept, got '%s'", result.Content)
+	}
+}
+
+func TestTallyKeyPoints_Vote(t *testing.T) {
+	keyPoints := []string{"A", "B", "C"}
+	judgments := []*keyPointJudgment{
+		{Covered: []int{1, 2, 3}},
+		{Covered: []int{1, 2}},
+		{Covered: []int{1}},
+	}
+
+	// Points covered by the majority are A and B, which is below the threshold of 1.0
+	result := tallyKeyPoints(localizerFor(nil), nil, judgments, keyPoints, 1.0, 0.5)
+	if len(result.Covered) != 2 {
+		t.Errorf("Expected 2 covered key points, got %v", result.Covered)
+	}
+	if result.Verdict != verdictIncorrect {
+		t.Errorf("Expected verdict to be %s, got %s", verdictIncorrect, result.Verdict)
+	}
+
+	// Requiring unanimous samples turns the disagreement into close
+	result = tallyKeyPoints(localizerFor(nil), nil, judgments, keyPoints, 1.0, 1.0)
+	if result.Verdict != verdictClose {
+		t.Errorf("Expected verdict to be %s, got %s", verdictClose, result.Verdict)
+	}
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+type answerVerdict string
+
+const (
+	verdictCorrect   answerVerdict = "正解"
+	verdictIncorrect answerVerdict = "不正解"
+	verdictClose     answerVerdict = "惜しい"
+)
+
+// judgeResult is the outcome of judging an answer, either from a single
+// completion or from a self-consistency vote over several completions.
+type judgeResult struct {
+	Verdict answerVerdict
+
+	// Content is the judgment text shown to the players
+	Content string
+
+	Correct   int
+	Incorrect int
+
+	// Confidence is the share of samples which agreed with the winning verdict
+	Confidence float64
+
+	// Covered is the 0-based indexes of the key points the answer covered
+	// TotalPoints is zero when the answer was not judged against key points
+	Covered     []int
+	TotalPoints int
+}
+
+func (r *judgeResult) Samples() int {
+	return r.Correct + r.Incorrect
+}
+
+func (r *judgeResult) Breakdown(l *localizer) string {
+	return l.T(msgJudgeBreakdown, r.Correct, r.Incorrect, r.Confidence*100, l.T(verdictKey(r.Verdict)))
+}
+
+// Progress returns the indicator of how many key points the answer covered
+func (r *judgeResult) Progress(l *localizer) string {
+	bar := strings.Repeat("■", len(r.Covered)) + strings.Repeat("□", r.TotalPoints-len(r.Covered))
+	return l.T(msgJudgeProgress, bar, len(r.Covered), r.TotalPoints)
+}
+
+// verdictKey returns the key of the label shown to the players for the verdict
+func verdictKey(verdict answerVerdict) messageKey {
+	switch verdict {
+	case verdictCorrect:
+		return msgVerdictCorrect
+	case verdictClose:
+		return msgVerdictClose
+	default:
+		return msgVerdictIncorrect
+	}
+}
+
+func classifyJudgment(judgment string) answerVerdict {
+	if strings.Contains(judgment, "不正解") {
+		return verdictIncorrect
+	}
+	return verdictCorrect
+}
+
+// tallyJudgments takes a vote over the sampled judgments. The verdict with the
+// most samples wins when its share reaches the agreement threshold, otherwise the
+// samples are considered to disagree and the answer is judged as close.
+func tallyJudgments(l *localizer, judgments []string, agreement float64) *judgeResult {
+	result := &judgeResult{}
+	var correctContent, incorrectContent string
+
+	for _, judgment := range judgments {
+		if classifyJudgment(judgment) == verdictCorrect {
+			result.Correct++
+			if correctContent == "" {
+				correctContent = judgment
+			}
+		} else {
+			result.Incorrect++
+			if incorrectContent == "" {
+				incorrectContent = judgment
+			}
+		}
+	}
+
+	if result.Samples() == 0 {
+		return result
+	}
+
+	winner, winnerVotes, content := verdictIncorrect, result.Incorrect, incorrectContent
+	if result.Correct > result.Incorrect {
+		winner, winnerVotes, content = verdictCorrect, result.Correct, correctContent
+	}
+	result.Confidence = float64(winnerVotes) / float64(result.Samples())
+
+	if result.Correct > 0 && result.Incorrect > 0 && (result.Correct == result.Incorrect || result.Confidence < agreement) {
+		result.Verdict = verdictClose
+		result.Content = l.T(msgJudgeCloseHint)
+		return result
+	}
+
+	result.Verdict = winner
+	result.Content = content
+	return result
+}
+
+// sampleJudgments asks the OpenAI API for the given number of judgments. The
+// samples are requested with n in a single call, and the remaining ones are
+// requested again when the API returns fewer choices than asked for.
+func (h *AnswerCommandHandler) sampleJudgments(base *domain.ChatCompletionRequest, samples int) ([]string, error) {
+	var judgments []string
+
+	for len(judgments) < samples {
+		remaining := samples - len(judgments)
+		req := *base
+		req.N = 0
+		if remaining > 1 {
+			req.N = remaining
+		}
+
+		h.logger.Info("Sending request to OpenAI API for %d judgment samples", remaining)
+		resp, err := h.openaiClient.CreateChatCompletion(&req)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("no choices in response")
+		}
+
+		for _, choice := range resp.Choices {
+			if len(judgments) == samples {
+				break
+			}
+			judgments = append(judgments, choice.Message.Content)
+		}
+	}
+
+	return judgments, nil
+}
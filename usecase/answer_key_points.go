@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// defaultKeyPointThreshold is the share of the key points an answer has to cover to be accepted
+const defaultKeyPointThreshold = 0.8
+
+// keyPointJudgment is a judgment of which key points of the solution the answer covers
+type keyPointJudgment struct {
+	// Covered is the 1-based numbers of the covered key points
+	Covered []int  `json:"covered"`
+	Comment string `json:"comment"`
+}
+
+func parseKeyPointJudgment(content string, total int) (*keyPointJudgment, error) {
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var judgment keyPointJudgment
+	if err := json.Unmarshal([]byte(content), &judgment); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal key point judgment: %w", err)
+	}
+
+	// Drop the numbers out of range and the duplicates
+	seen := make(map[int]bool)
+	covered := make([]int, 0, len(judgment.Covered))
+	for _, number := range judgment.Covered {
+		if number < 1 || number > total || seen[number] {
+			continue
+		}
+		seen[number] = true
+		covered = append(covered, number)
+	}
+	sort.Ints(covered)
+	judgment.Covered = covered
+	judgment.Comment = strings.TrimSpace(judgment.Comment)
+
+	return &judgment, nil
+}
+
+// keyPointMessages builds the messages which ask the model to check the answer
+// against each key point of the solution.
+func keyPointMessages(prompt string, game *domain.Game, quiz string, answer string) []domain.ChatMessage {
+	messages := []domain.ChatMessage{
+		{
+			Role:    "system",
+			Content: prompt,
+		},
+	}
+
+	if solution, ok := solutionMessage(game, false); ok {
+		messages = append(messages, solution)
+	}
+
+	var points strings.Builder
+	points.WriteString("判定する要素の一覧:")
+	for idx, point := range game.KeyPoints {
+		points.WriteString(fmt.Sprintf("\n%d. %s", idx+1, point))
+	}
+	messages = append(messages,
+		domain.ChatMessage{Role: "system", Content: points.String()},
+		domain.ChatMessage{Role: "assistant", Content: quiz},
+		domain.ChatMessage{Role: "user", Content: "回答: " + answer},
+	)
+
+	return messages
+}
+
+// tallyKeyPoints combines the sampled judgments. A key point is covered when the
+// majority of the samples say so, and the answer is accepted when the covered
+// share reaches the threshold. When the samples disagree about accepting the
+// answer below the agreement threshold, the answer is judged as close. The comment
+// is dropped when the leak guard finds the key points not covered yet in it.
+func tallyKeyPoints(l *localizer, leakGuard *LeakGuard, judgments []*keyPointJudgment, keyPoints []string, threshold, agreement float64) *judgeResult {
+	total := len(keyPoints)
+	result := &judgeResult{TotalPoints: total}
+	if len(judgments) == 0 || total == 0 {
+		return result
+	}
+
+	votes := make([]int, total)
+	for _, judgment := range judgments {
+		for _, number := range judgment.Covered {
+			votes[number-1]++
+		}
+		if accepted(len(judgment.Covered), total, threshold) {
+			result.Correct++
+		} else {
+			result.Incorrect++
+		}
+	}
+
+	for idx, count := range votes {
+		if count*2 > len(judgments) {
+			result.Covered = append(result.Covered, idx)
+		}
+	}
+
+	verdict, agreeing := verdictIncorrect, result.Incorrect
+	if accepted(len(result.Covered), total, threshold) {
+		verdict, agreeing = verdictCorrect, result.Correct
+	}
+	result.Confidence = float64(agreeing) / float64(len(judgments))
+
+	var comment string
+	for _, judgment := range judgments {
+		if accepted(len(judgment.Covered), total, threshold) == (verdict == verdictCorrect) {
+			comment = judgment.Comment
+			break
+		}
+	}
+
+	if result.Correct > 0 && result.Incorrect > 0 && result.Confidence < agreement {
+		verdict = verdictClose
+		comment = ""
+	}
+	result.Verdict = verdict
+
+	// The covered key points are shown anyway, so only the others are kept secret
+	if comment != "" && leakGuard != nil {
+		var uncovered []string
+		for idx, point := range keyPoints {
+			if !slices.Contains(result.Covered, idx) {
+				uncovered = append(uncovered, point)
+			}
+		}
+		if len(uncovered) > 0 {
+			if reason, leaked := leakGuard.CheckOutput(comment, uncovered...); leaked {
+				leakGuard.RecordBlocked("answer", "output", reason, comment)
+				comment = ""
+			}
+		}
+	}
+
+	var b strings.Builder
+	switch verdict {
+	case verdictCorrect:
+		b.WriteString(l.T(msgJudgeCorrect))
+	case verdictClose:
+		b.WriteString(l.T(msgJudgeClose))
+	default:
+		b.WriteString(l.T(msgJudgeIncorrect))
+	}
+	b.WriteString("\n" + result.Progress(l))
+	for _, idx := range result.Covered {
+		b.WriteString("\n✅ " + keyPoints[idx])
+	}
+	if comment != "" {
+		b.WriteString("\n\n" + comment)
+	}
+	result.Content = b.String()
+
+	return result
+}
+
+func accepted(covered, total int, threshold float64) bool {
+	return float64(covered) >= threshold*float64(total)
+}
+
+// judgeKeyPoints judges the answer against the key points of the stored solution.
+func (h *AnswerCommandHandler) judgeKeyPoints(l *localizer, prompt string, game *domain.Game, quiz string, answer string) (*judgeResult, error) {
+	req := &domain.ChatCompletionRequest{
+		Model:          "chatgpt-4o-latest",
+		Messages:       keyPointMessages(prompt, game, quiz, answer),
+		Temperature:    0.7,
+		ResponseFormat: &domain.ResponseFormat{Type: "json_object"},
+	}
+
+	contents, err := h.sampleJudgments(req, h.judgeSamples)
+	if err != nil {
+		return nil, err
+	}
+
+	var judgments []*keyPointJudgment
+	for _, content := range contents {
+		judgment, err := parseKeyPointJudgment(content, len(game.KeyPoints))
+		if err != nil {
+			h.logger.Error("Failed to parse key point judgment: %v", err)
+			continue
+		}
+		judgments = append(judgments, judgment)
+	}
+
+	if len(judgments) == 0 {
+		return nil, fmt.Errorf("no valid key point judgment")
+	}
+
+	return tallyKeyPoints(l, h.leakGuard, judgments, game.KeyPoints, h.keyPointThreshold, h.judgeAgreement), nil
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// Policy decides who may use a command ending the game.
+// The command is allowed when any of the rules allows it.
+type Policy struct {
+	// Creator allows the user who created the game
+	Creator bool
+
+	// Roles allows the members with any of the roles, such as the game masters
+	Roles []string
+
+	// Vote lets the other users end the game by the vote of the players
+	Vote bool
+}
+
+// ParsePolicy parses the rules separated by commas, such as "creator,game_master,vote".
+// The game master rule allows the members with any of the roles. Only an empty spec
+// or "anyone" alone returns nil, which allows everyone, so that a typo such as a
+// trailing comma never opens the command to everyone.
+func ParsePolicy(spec string, roles []string) (*Policy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "anyone" {
+		return nil, nil
+	}
+
+	policy := &Policy{}
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		switch rule {
+		case "":
+			continue
+		case "anyone":
+			return nil, fmt.Errorf("anyone can not be combined with the other rules: %s", spec)
+		case "creator":
+			policy.Creator = true
+		case "game_master":
+			if len(roles) == 0 {
+				return nil, fmt.Errorf("no game master role for the rule: %s", rule)
+			}
+			policy.Roles = roles
+		case "vote":
+			policy.Vote = true
+		default:
+			return nil, fmt.Errorf("unknown rule: %s", rule)
+		}
+	}
+
+	if !policy.Creator && len(policy.Roles) == 0 && !policy.Vote {
+		return nil, fmt.Errorf("no rule in the policy: %s", spec)
+	}
+
+	return policy, nil
+}
+
+// authorization is how the user may use the command ending the game
+type authorization int
+
+const (
+	// authAnyone is for the commands without the policy
+	authAnyone authorization = iota
+
+	// authAllowed is for the users allowed by the creator or the role rule, who end the game alone
+	authAllowed
+
+	// authVote is for the users who may end the game only by a vote
+	authVote
+
+	// authDenied is for the users who may not use the command
+	authDenied
+)
+
+// Authorizer enforces the policies of the commands ending the game, such as /quit and /giveup
+type Authorizer struct {
+	gameStore domain.GameStore
+	logger    domain.Logger
+	policies  map[string]*Policy
+}
+
+func NewAuthorizer(gameStore domain.GameStore, logger domain.Logger) *Authorizer {
+	return &Authorizer{
+		gameStore: gameStore,
+		logger:    logger,
+		policies:  make(map[string]*Policy),
+	}
+}
+
+// SetPolicy sets the policy of the command, or allows everyone with nil
+func (a *Authorizer) SetPolicy(command string, policy *Policy) {
+	a.policies[command] = policy
+}
+
+// NeedsVoting returns true when any of the policies lets the users end the game by a vote
+func (a *Authorizer) NeedsVoting() bool {
+	for _, policy := range a.policies {
+		if policy != nil && policy.Vote {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize decides how the user may use the command on the game
+func (a *Authorizer) authorize(command string, i *domain.InteractionCreate, game *domain.Game) authorization {
+	policy := a.policies[command]
+	if policy == nil {
+		return authAnyone
+	}
+	if i.User == nil {
+		return authDenied
+	}
+
+	// Nobody is the creator of the games without the recorded creator, so the other rules decide
+	if policy.Creator && game.CreatorID != "" && game.CreatorID == i.User.ID {
+		return authAllowed
+	}
+	if len(policy.Roles) > 0 && i.Member != nil && i.Member.HasRole(policy.Roles...) {
+		return authAllowed
+	}
+	if policy.Vote {
+		return authVote
+	}
+
+	return authDenied
+}
+
+// Check decides how the user may use the command. It responds to the interaction
+// when the user is denied.
+func (a *Authorizer) Check(s domain.Session, i *domain.InteractionCreate, command string) authorization {
+	if a == nil || a.policies[command] == nil {
+		return authAnyone
+	}
+
+	game, err := a.gameStore.Load()
+	if err != nil {
+		a.logger.Error("Failed to load game record: %v", err)
+		a.respond(s, i, &domain.InteractionResponseData{Content: localizerFor(i).T(msgFailure)})
+		return authDenied
+	}
+
+	result := a.authorize(command, i, game)
+	if result == authDenied {
+		a.deny(s, i, command)
+	} else {
+		a.logger.Info("Authorized %s command: %d", command, result)
+	}
+
+	return result
+}
+
+// deny tells the user ephemerally that the command is not allowed
+func (a *Authorizer) deny(s domain.Session, i *domain.InteractionCreate, command string) {
+	a.logger.Info("Denied %s command", command)
+	l := localizerFor(i)
+	a.respond(s, i, &domain.InteractionResponseData{
+		Content: l.T(msgAuthDenied, l.Command(command)),
+		Flags:   domain.MessageFlagsEphemeral,
+	})
+}
+
+func (a *Authorizer) respond(s domain.Session, i *domain.InteractionCreate, data *domain.InteractionResponseData) {
+	if err := s.InteractionRespond(i, &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseChannelMessageWithSource),
+		Data: data,
+	}); err != nil {
+		a.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// endGame runs the command ending the game as the policy and the voting decide.
+// The users allowed by a rule end the game alone. The others start a vote when the
+// policy lets them, or when the command has no policy and the voting is on.
+func endGame(s domain.Session, i *domain.InteractionCreate, command string, authorizer *Authorizer, voting *Voting, finish func(s domain.Session, i *domain.InteractionCreate)) {
+	switch authorizer.Check(s, i, command) {
+	case authAllowed:
+		finish(s, i)
+	case authAnyone:
+		if voting != nil {
+			voting.Start(s, i, command)
+			return
+		}
+		finish(s, i)
+	case authVote:
+		// The vote rule never lets the user end the game alone
+		if voting == nil {
+			authorizer.deny(s, i, command)
+			return
+		}
+		voting.Start(s, i, command)
+	}
+}
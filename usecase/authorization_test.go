@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("creator, game_master, vote", []string{"gm-role"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !policy.Creator || len(policy.Roles) != 1 || !policy.Vote {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	// Everyone is allowed without the rules
+	for _, spec := range []string{"", "anyone"} {
+		if policy, err := ParsePolicy(spec, nil); policy != nil || err != nil {
+			t.Errorf("Expected no policy for %q, got %+v, %v", spec, policy, err)
+		}
+	}
+
+	// The empty rules are skipped rather than allowing everyone
+	policy, err = ParsePolicy("creator,", nil)
+	if err != nil || policy == nil || !policy.Creator {
+		t.Errorf("Expected the creator policy with a trailing comma, got %+v, %v", policy, err)
+	}
+
+	for _, spec := range []string{"vote:3", "game_master", "admin", "creator,anyone", ","} {
+		if _, err := ParsePolicy(spec, nil); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestAuthorizer_Authorize(t *testing.T) {
+	authorizer := NewAuthorizer(nil, nil)
+	authorizer.SetPolicy("quit", &Policy{Creator: true, Roles: []string{"gm-role"}})
+	authorizer.SetPolicy("giveup", &Policy{Creator: true, Vote: true})
+
+	game := &domain.Game{CreatorID: "creator"}
+	tests := []struct {
+		name    string
+		command string
+		i       *domain.InteractionCreate
+		want    authorization
+	}{
+		{"creator", "quit", &domain.InteractionCreate{User: &domain.User{ID: "creator"}}, authAllowed},
+		{"game master", "quit", &domain.InteractionCreate{User: &domain.User{ID: "gm"}, Member: &domain.Member{Roles: []string{"other-role", "gm-role"}}}, authAllowed},
+		{"player", "quit", &domain.InteractionCreate{User: &domain.User{ID: "player"}, Member: &domain.Member{Roles: []string{"other-role"}}}, authDenied},
+		{"unknown user", "quit", &domain.InteractionCreate{}, authDenied},
+		{"creator with the vote rule", "giveup", &domain.InteractionCreate{User: &domain.User{ID: "creator"}}, authAllowed},
+		{"player with the vote rule", "giveup", &domain.InteractionCreate{User: &domain.User{ID: "player"}}, authVote},
+		{"command without the policy", "clue", &domain.InteractionCreate{}, authAnyone},
+	}
+
+	for _, tt := range tests {
+		if result := authorizer.authorize(tt.command, tt.i, game); result != tt.want {
+			t.Errorf("Expected %s to be %d, got %d", tt.name, tt.want, result)
+		}
+	}
+
+	// Nobody is the creator of the game without the recorded creator
+	if result := authorizer.authorize("quit", &domain.InteractionCreate{User: &domain.User{ID: "player"}}, &domain.Game{}); result != authDenied {
+		t.Errorf("Expected the player to be denied on the game without the creator, got %d", result)
+	}
+
+	if !authorizer.NeedsVoting() {
+		t.Errorf("Expected the vote rule to need the voting")
+	}
+}
+
+func TestAuthorizer_Check(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{CreatorID: "creator"}, nil).Times(2)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	authorizer := NewAuthorizer(mockGameStore, mockLogger)
+	authorizer.SetPolicy("quit", &Policy{Creator: true})
+
+	// The denied user is told ephemerally
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Flags&domain.MessageFlagsEphemeral == 0 || r.Data.Content != "`/終了` を使う権限がありません。クイズの作成者かゲームマスターに頼んでください。" {
+				t.Errorf("Unexpected response: %+v", r.Data)
+			}
+			return nil
+		})
+
+	if result := authorizer.Check(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "player"}}, "quit"); result != authDenied {
+		t.Errorf("Expected the player to be denied, got %d", result)
+	}
+
+	// The creator is allowed without any response
+	if result := authorizer.Check(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "creator"}}, "quit"); result != authAllowed {
+		t.Errorf("Expected the creator to be allowed, got %d", result)
+	}
+
+	// Nothing is checked without the authorizer
+	var none *Authorizer
+	if result := none.Check(mockSession, &domain.InteractionCreate{}, "quit"); result != authAnyone {
+		t.Errorf("Expected the command to be allowed without the authorizer, got %d", result)
+	}
+}
+
+func TestEndGame(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{CreatorID: "creator"}, nil).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	authorizer := NewAuthorizer(mockGameStore, mockLogger)
+	authorizer.SetPolicy("giveup", &Policy{Creator: true, Vote: true})
+
+	finished := 0
+	finish := func(s domain.Session, i *domain.InteractionCreate) { finished++ }
+
+	// The creator ends the game alone
+	endGame(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "creator"}}, "giveup", authorizer, nil, finish)
+	if finished != 1 {
+		t.Errorf("Expected the creator to end the game, got %d", finished)
+	}
+
+	// The vote rule never ends the game alone without the voting
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected the ephemeral denial, got %+v", r.Data)
+			}
+			return nil
+		})
+	endGame(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "player"}}, "giveup", authorizer, nil, finish)
+	if finished != 1 {
+		t.Errorf("Expected the player not to end the game, got %d", finished)
+	}
+
+	// Everyone ends the game alone without the policy and the voting
+	endGame(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "player"}}, "quit", authorizer, nil, finish)
+	if finished != 2 {
+		t.Errorf("Expected the player to end the game without the policy, got %d", finished)
+	}
+}
@@ -1,16 +1,57 @@
 package usecase
 
 import (
-	"github.com/bwmarrin/discordgo"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/gong023/umi/domain"
-	"github.com/gong023/umi/infra"
 )
 
+// defaultShutdownTimeout is how long the shutdown waits for the interactions in flight
+const defaultShutdownTimeout = 30 * time.Second
+
+// errShutdownTimeout is returned when the interactions are still running at the shutdown deadline.
+// The process should exit right away then, since the handlers left running keep touching the game.
+var errShutdownTimeout = errors.New("shutdown deadline exceeded before the interactions finished")
+
 type BotService struct {
 	discordClient domain.DiscordClient
 	openaiClient  domain.OpenAIClient
 	logger        domain.Logger
-	commands      map[string]domain.CommandHandler
+	commands      map[string]domain.Command
+	components    map[string]domain.CommandHandler
+
+	// middlewares wrap every handler in the order of their registration
+	middlewares []domain.Middleware
+
+	// startHooks run once the bot started, such as to resume the votes kept over the restart
+	startHooks []func(session domain.Session)
+
+	// messageHandler handles the plain messages, or nil when the message mode is off
+	messageHandler domain.MessageHandler
+
+	// dispatcher runs the handlers on its workers, or nil to run them on the event goroutines
+	dispatcher *Dispatcher
+
+	// shutdownTimeout is how long Stop waits for the interactions in flight
+	shutdownTimeout time.Duration
+
+	// ackTimeout is how long the interactions wait for their handler before the bot acknowledges them itself
+	ackTimeout time.Duration
+
+	// stopping is set once Stop is called, after which the new interactions are turned away
+	stopping bool
+
+	// inflight counts the interactions and the messages accepted but not finished yet
+	inflight sync.WaitGroup
+
+	// mu guards stopping, so that no interaction is accepted after Stop starts waiting
+	mu sync.Mutex
+
+	// commandGuildID is the guild the commands are registered to, or empty for the global commands
+	commandGuildID string
 }
 
 func NewBotService(discordClient domain.DiscordClient, openaiClient domain.OpenAIClient, logger domain.Logger) *BotService {
@@ -18,95 +59,323 @@ func NewBotService(discordClient domain.DiscordClient, openaiClient domain.OpenA
 		discordClient: discordClient,
 		openaiClient:  openaiClient,
 		logger:        logger,
-		commands:      make(map[string]domain.CommandHandler),
+		commands:      make(map[string]domain.Command),
+		components:    make(map[string]domain.CommandHandler),
+
+		shutdownTimeout: defaultShutdownTimeout,
+		ackTimeout:      defaultAckTimeout,
 	}
 }
 
 func (s *BotService) Start() error {
 	s.logger.Info("Starting bot service")
 
+	// Start the workers before the events arrive
+	if s.dispatcher != nil {
+		s.dispatcher.Start()
+	}
+
 	// Start the Discord client first so we have a valid session
 	if err := s.discordClient.Start(); err != nil {
 		return err
 	}
 
-	// Register the interaction create handler
-	// Use a function that matches the expected signature for discordgo handlers
-	s.discordClient.RegisterHandler(func(session *discordgo.Session, i *discordgo.InteractionCreate) {
-		s.handleInteractionCreate(session, i)
-	})
+	// Register the interaction handler, which receives the interactions already converted by infra
+	s.discordClient.OnInteraction(s.handleInteraction)
 
-	// Register commands with Discord API
-	commands := make([]*domain.ApplicationCommand, 0, len(s.commands))
-	for name := range s.commands {
-		commands = append(commands, &domain.ApplicationCommand{
-			Name:        name,
-			Description: name + " command",
-		})
+	// Register the message handler only when the message mode is on
+	if s.messageHandler != nil {
+		s.discordClient.OnMessage(s.handleMessage)
 	}
 
-	if err := s.discordClient.RegisterCommands(commands); err != nil {
+	// Sync commands with Discord API
+	if err := s.syncCommands(); err != nil {
 		return err
 	}
 
+	// Run the start hooks with the session of the client, since they have no interaction to respond to
+	if len(s.startHooks) > 0 {
+		session := s.discordClient.Session()
+		for _, hook := range s.startHooks {
+			hook(session)
+		}
+	}
+
 	return nil
 }
 
+// Stop shuts the bot down gracefully. The new interactions are told that the
+// bot is restarting, and the ones in flight are waited for until the shutdown
+// timeout so that the games are saved, before the gateway is closed. The
+// commands are left registered, so that they are available again as soon as
+// the bot restarts.
+//
+// The handlers take no context, so they can not be cancelled at the deadline.
+// When Stop returns errShutdownTimeout, the handlers still running and the
+// workers waiting for them are left behind, and the process must exit right
+// away instead of starting the bot again in the same process.
 func (s *BotService) Stop() error {
 	s.logger.Info("Stopping bot service")
 
-	// Delete all commands
-	s.logger.Info("Deleting all commands")
-	if err := s.discordClient.DeleteCommands(); err != nil {
-		s.logger.Error("Failed to delete commands: %v", err)
-		// Continue with shutdown even if command deletion fails
+	// Turn away the new interactions
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+
+	// Wait for the interactions already accepted, and the workers running them
+	drained := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		if s.dispatcher != nil {
+			s.dispatcher.Stop()
+		}
+		close(drained)
+	}()
+
+	var drainErr error
+	select {
+	case <-drained:
+		s.logger.Info("All interactions finished")
+	case <-time.After(s.shutdownTimeout):
+		s.logger.Error("Shutdown timeout %s exceeded, closing with the interactions still running", s.shutdownTimeout)
+		drainErr = errShutdownTimeout
 	}
 
 	// Stop the Discord client
-	return s.discordClient.Stop()
+	if err := s.discordClient.Stop(); err != nil {
+		return err
+	}
+
+	return drainErr
+}
+
+// SetCommandGuild registers the commands to the guild instead of globally
+// Guild commands are updated immediately, which is useful for testing servers
+func (s *BotService) SetCommandGuild(guildID string) {
+	s.commandGuildID = guildID
+}
+
+// SetShutdownTimeout sets how long Stop waits for the interactions in flight
+func (s *BotService) SetShutdownTimeout(timeout time.Duration) {
+	s.shutdownTimeout = timeout
+}
+
+// accept counts the interaction or the message in flight, and returns false once the bot is stopping
+func (s *BotService) accept() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping {
+		return false
+	}
+	s.inflight.Add(1)
+	return true
+}
+
+// SetDispatcher runs the handlers on the workers of the dispatcher instead of the event goroutines
+func (s *BotService) SetDispatcher(dispatcher *Dispatcher) {
+	s.dispatcher = dispatcher
 }
 
-func (s *BotService) RegisterCommand(name string, handler domain.CommandHandler) {
+func (s *BotService) RegisterCommand(command domain.Command) {
+	name := command.Definition().Name
 	s.logger.Info("Registering command: %s", name)
-	s.commands[name] = handler
+	s.commands[name] = command
 }
 
-func (s *BotService) handleInteractionCreate(session *discordgo.Session, i *discordgo.InteractionCreate) {
-	s.logger.Info("Received interaction event")
+// definitions returns the definitions declared by the registered commands in the order of their names
+func (s *BotService) definitions() []*domain.ApplicationCommand {
+	names := make([]string, 0, len(s.commands))
+	for name := range s.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := make([]*domain.ApplicationCommand, 0, len(names))
+	for _, name := range names {
+		definitions = append(definitions, s.commands[name].Definition())
+	}
+
+	return definitions
+}
 
-	// Check if this is an application command (slash command)
-	// Type 2 is APPLICATION_COMMAND
-	if i.Type != discordgo.InteractionApplicationCommand {
-		s.logger.Info("Ignoring non-application command interaction: %d", i.Type)
+// RegisterStartHook runs the hook once the bot started and synced the commands
+func (s *BotService) RegisterStartHook(hook func(session domain.Session)) {
+	s.startHooks = append(s.startHooks, hook)
+}
+
+func (s *BotService) RegisterComponent(customID string, handler domain.CommandHandler) {
+	s.logger.Info("Registering component: %s", customID)
+	s.components[customID] = handler
+}
+
+// Use wraps every handler with the middlewares. The middlewares registered
+// first are the outer ones, so they run before and finish after the later ones.
+func (s *BotService) Use(middlewares ...domain.Middleware) {
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// chain wraps the handler with the middlewares
+func (s *BotService) chain(handler domain.CommandHandler) domain.CommandHandler {
+	for idx := len(s.middlewares) - 1; idx >= 0; idx-- {
+		handler = s.middlewares[idx](handler)
+	}
+	return handler
+}
+
+// Handler returns the handler routing the interactions to the registered handlers
+// through the middlewares, for the interactions made from the plain messages
+func (s *BotService) Handler() domain.CommandHandler {
+	return domain.HandlerFunc(s.dispatch)
+}
+
+// RegisterMessageHandler turns on the message mode, where the plain messages are handled by the handler
+func (s *BotService) RegisterMessageHandler(handler domain.MessageHandler) {
+	s.logger.Info("Registering message handler")
+	s.messageHandler = handler
+}
+
+func (s *BotService) handleMessage(session domain.Session, message *domain.MessageCreate) {
+	// The messages are not answered while restarting, since they can not be answered ephemerally
+	if !s.accept() {
+		s.logger.Info("Ignored message %s: bot is stopping", message.ID)
+		return
+	}
+
+	handle := func() {
+		defer s.inflight.Done()
+		s.messageHandler.HandleMessage(session, message)
+	}
+	if s.dispatcher == nil {
+		handle()
 		return
 	}
 
-	s.logger.Info("Received application command interaction")
+	// The messages are asked or judged on the game, so they wait behind the other interactions on it
+	if !s.dispatcher.Submit(gameQueueKey, handle) {
+		s.inflight.Done()
+		s.logger.Error("Dropped message %s: dispatcher is busy", message.ID)
 
-	// Get the command name from the interaction data
-	commandName := i.ApplicationCommandData().Name
-	s.logger.Info("Command name: %s", commandName)
+		// Tell the user to try again, as the interactions turned away are told
+		data := &domain.InteractionResponseData{Content: localizerFor(nil).T(msgBusy)}
+		if err := session.ReplyMessage(message.ChannelID, message.ID, data); err != nil {
+			s.logger.Error("Failed to reply to message: %v", err)
+		}
+	}
+}
 
-	// Find the handler for this command
-	handler, ok := s.commands[commandName]
-	if !ok {
-		s.logger.Debug("No handler for command: %s", commandName)
+func (s *BotService) handleInteraction(session domain.Session, interaction *domain.InteractionCreate) {
+	s.logger.Info("Received interaction event")
+	s.submit(session, interaction)
+}
+
+// submit dispatches the interaction on the workers of the dispatcher, and tells
+// the user to try again when the bot is restarting or too many interactions are waiting
+func (s *BotService) submit(session domain.Session, interaction *domain.InteractionCreate) {
+	if !s.accept() {
+		s.logger.Info("Rejected interaction %s: bot is stopping", interaction.ID)
+		s.respondUnavailable(session, interaction, msgRestarting)
 		return
 	}
 
-	// Convert the interaction to our domain model
-	interaction := infra.ConvertInteraction(i)
-	if interaction == nil {
-		s.logger.Error("Failed to convert discordgo.InteractionCreate to domain.InteractionCreate")
+	// The interaction may wait behind the others, or its handler may take longer than Discord
+	// waits for the response, so it is acknowledged without the handler once the timeout passes
+	ack := newAckSession(session, interaction, s.ackTimeout, s.logger)
+	if s.dispatcher == nil {
+		defer s.inflight.Done()
+		defer ack.done()
+		s.dispatch(ack, interaction)
+		return
+	}
+
+	handle := func() {
+		defer s.inflight.Done()
+		defer ack.done()
+		s.dispatch(ack, interaction)
+	}
+	if s.dispatcher.Submit(dispatchKey(interaction), handle) {
 		return
 	}
 
-	// Create a session wrapper
-	discordSession := infra.NewSession(session)
+	ack.done()
+	s.inflight.Done()
+	s.logger.Error("Rejected interaction %s: dispatcher is busy", interaction.ID)
+	s.respondUnavailable(session, interaction, msgBusy)
+}
+
+// respondUnavailable tells the user ephemerally that the interaction was not handled
+func (s *BotService) respondUnavailable(session domain.Session, interaction *domain.InteractionCreate, key messageKey) {
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseChannelMessageWithSource),
+		Data: &domain.InteractionResponseData{
+			Content: localizerFor(interaction).T(key),
+			Flags:   domain.MessageFlagsEphemeral,
+		},
+	}
+	if err := session.InteractionRespond(interaction, response); err != nil {
+		s.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// dispatch routes the interaction to its handler
+// Application commands are routed by the command name, and message components and modals by the custom ID
+func (s *BotService) dispatch(session domain.Session, interaction *domain.InteractionCreate) {
+	var handler domain.CommandHandler
+
+	switch domain.InteractionType(interaction.Type) {
+	case domain.InteractionApplicationCommand:
+		s.logger.Info("Received application command interaction")
+
+		// Get the command name from the interaction data
+		commandName := interaction.Data.Name
+		s.logger.Info("Command name: %s", commandName)
+
+		// Find the handler for this command
+		command, ok := s.commands[commandName]
+		if !ok {
+			s.logger.Debug("No handler for command: %s", commandName)
+			s.respondUnavailable(session, interaction, msgUnavailable)
+			return
+		}
+		handler = command
+	case domain.InteractionMessageComponent:
+		s.logger.Info("Received message component interaction")
+
+		// Get the custom ID from the component data
+		customID := interaction.Component.CustomID
+		s.logger.Info("Custom ID: %s", customID)
+
+		// Find the handler for this component
+		component, ok := s.components[customID]
+		if !ok {
+			s.logger.Debug("No handler for component: %s", customID)
+			s.respondUnavailable(session, interaction, msgUnavailable)
+			return
+		}
+		handler = component
+	case domain.InteractionModalSubmit:
+		s.logger.Info("Received modal submit interaction")
+
+		// Get the custom ID from the modal data
+		customID := interaction.Modal.CustomID
+		s.logger.Info("Custom ID: %s", customID)
+
+		// Find the handler for this modal
+		component, ok := s.components[customID]
+		if !ok {
+			s.logger.Debug("No handler for modal: %s", customID)
+			s.respondUnavailable(session, interaction, msgUnavailable)
+			return
+		}
+		handler = component
+	default:
+		s.logger.Info("Ignoring unsupported interaction: %d", interaction.Type)
+		return
+	}
 
-	// Call the handler
-	s.logger.Info("Found handler for command: %s, calling Handle", commandName)
-	handler.Handle(discordSession, interaction)
+	// Call the handler through the middlewares
+	s.logger.Info("Found handler, calling Handle")
+	s.chain(handler).Handle(session, interaction)
 }
 
 type Session struct {
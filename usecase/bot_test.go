@@ -3,7 +3,9 @@ package usecase
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/gong023/umi/domain"
 	"github.com/gong023/umi/infra/mock"
 	"go.uber.org/mock/gomock"
 )
@@ -28,8 +30,8 @@ func TestBotService_Start(t *testing.T) {
 
 	// Set up expectations
 	mockDiscordClient.EXPECT().Start().Return(nil)
-	mockDiscordClient.EXPECT().RegisterHandler(gomock.Any()).Return(func() {})
-	mockDiscordClient.EXPECT().RegisterCommands(gomock.Any()).Return(nil)
+	mockDiscordClient.EXPECT().OnInteraction(gomock.Any())
+	mockDiscordClient.EXPECT().Commands("").Return(nil, nil)
 
 	// Start the bot
 	err := botService.Start()
@@ -89,7 +91,6 @@ func TestBotService_Stop(t *testing.T) {
 	botService := NewBotService(mockDiscordClient, mockOpenAIClient, mockLogger)
 
 	// Set up expectations
-	mockDiscordClient.EXPECT().DeleteCommands().Return(nil)
 	mockDiscordClient.EXPECT().Stop().Return(nil)
 
 	// Stop the bot
@@ -101,7 +102,7 @@ func TestBotService_Stop(t *testing.T) {
 	}
 }
 
-func TestBotService_Stop_DeleteCommandsError(t *testing.T) {
+func TestBotService_RegisterCommand(t *testing.T) {
 	// Create a mock controller
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -115,25 +116,88 @@ func TestBotService_Stop_DeleteCommandsError(t *testing.T) {
 	// Create a mock logger
 	mockLogger := mock.NewMockLogger(ctrl)
 	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
-	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
 
 	// Create the bot service
 	botService := NewBotService(mockDiscordClient, mockOpenAIClient, mockLogger)
 
-	// Set up expectations
-	mockDiscordClient.EXPECT().DeleteCommands().Return(errors.New("delete commands error"))
-	mockDiscordClient.EXPECT().Stop().Return(nil)
+	// Create a mock command handler
+	mockCommandHandler := mock.NewMockCommand(ctrl)
+	mockCommandHandler.EXPECT().Definition().Return(&domain.ApplicationCommand{Name: "test"})
 
-	// Stop the bot
-	err := botService.Stop()
+	// Register the command
+	botService.RegisterCommand(mockCommandHandler)
 
-	// Check that there was no error (we continue even if DeleteCommands fails)
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+	// Check that the command was registered
+	handler, ok := botService.commands["test"]
+	if !ok {
+		t.Error("Expected command to be registered")
+	}
+	if handler != mockCommandHandler {
+		t.Error("Expected registered handler to be the mock handler")
 	}
 }
 
-func TestBotService_RegisterCommand(t *testing.T) {
+func TestBotService_Dispatch(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create the handlers
+	commandHandler := mock.NewMockCommand(ctrl)
+	commandHandler.EXPECT().Definition().Return(&domain.ApplicationCommand{Name: "clue"})
+	componentHandler := mock.NewMockCommandHandler(ctrl)
+	modalHandler := mock.NewMockCommandHandler(ctrl)
+
+	// Create the bot service
+	botService := NewBotService(mock.NewMockDiscordClient(ctrl), mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.RegisterCommand(commandHandler)
+	botService.RegisterComponent(CustomIDClue, componentHandler)
+	botService.RegisterComponent(CustomIDAnswerModal, modalHandler)
+
+	command := &domain.InteractionCreate{
+		Type: int(domain.InteractionApplicationCommand),
+		Data: &domain.ApplicationCommandInteractionData{Name: "clue"},
+	}
+	component := &domain.InteractionCreate{
+		Type:      int(domain.InteractionMessageComponent),
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDClue},
+	}
+	modal := &domain.InteractionCreate{
+		Type:  int(domain.InteractionModalSubmit),
+		Modal: &domain.ModalSubmitInteractionData{CustomID: CustomIDAnswerModal},
+	}
+	unknown := &domain.InteractionCreate{
+		Type:      int(domain.InteractionMessageComponent),
+		Component: &domain.MessageComponentInteractionData{CustomID: "unknown"},
+	}
+
+	// Each interaction is routed to its own handler, and unknown ones are told the command is not available
+	commandHandler.EXPECT().Handle(mockSession, command)
+	componentHandler.EXPECT().Handle(mockSession, component)
+	modalHandler.EXPECT().Handle(mockSession, modal)
+	mockSession.EXPECT().InteractionRespond(unknown, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != localizerFor(i).T(msgUnavailable) || r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral unavailable message, got %+v", r.Data)
+			}
+			return nil
+		})
+
+	botService.dispatch(mockSession, command)
+	botService.dispatch(mockSession, component)
+	botService.dispatch(mockSession, modal)
+	botService.dispatch(mockSession, unknown)
+}
+
+func TestBotService_Start_Definitions(t *testing.T) {
 	// Create a mock controller
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -141,28 +205,171 @@ func TestBotService_RegisterCommand(t *testing.T) {
 	// Create a mock Discord client
 	mockDiscordClient := mock.NewMockDiscordClient(ctrl)
 
-	// Create a mock OpenAI client
-	mockOpenAIClient := mock.NewMockOpenAIClient(ctrl)
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create the bot service with the commands declaring their own definitions
+	botService := NewBotService(mockDiscordClient, mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.RegisterCommand(NewQCommandHandler(nil, nil, nil, nil, mockLogger))
+	botService.RegisterCommand(NewAnswerCommandHandler(nil, nil, nil, nil, mockLogger))
+	botService.RegisterCommand(NewPingCommandHandler(mockLogger))
+
+	// The registered definitions are generated from the declarations
+	mockDiscordClient.EXPECT().Start().Return(nil)
+	mockDiscordClient.EXPECT().OnInteraction(gomock.Any())
+	mockDiscordClient.EXPECT().Commands("").Return(nil, nil)
+	mockDiscordClient.EXPECT().OverwriteCommands("", gomock.Any()).DoAndReturn(
+		func(guildID string, commands []*domain.ApplicationCommand) error {
+			if len(commands) != 3 {
+				t.Fatalf("Expected 3 commands, got %d", len(commands))
+			}
+
+			answer, ping, q := commands[0], commands[1], commands[2]
+			if answer.Name != "answer" || ping.Name != "ping" || q.Name != "q" {
+				t.Errorf("Expected the commands in the order of their names, got %s, %s, %s", answer.Name, ping.Name, q.Name)
+			}
+			if len(q.Options) != 1 || !q.Options[0].Required || q.Options[0].Type != domain.ApplicationCommandOptionString {
+				t.Errorf("Expected a required string option for q, got %+v", q.Options)
+			}
+			if len(answer.Options) != 1 || answer.Options[0].Required {
+				t.Errorf("Expected an optional option for answer, got %+v", answer.Options)
+			}
+			if !ping.DMPermission || q.DMPermission {
+				t.Errorf("Expected only ping to be allowed in direct messages")
+			}
+			for _, command := range commands {
+				if command.Description == "" || command.Description == command.Name+" command" {
+					t.Errorf("Expected a description for %s, got %q", command.Name, command.Description)
+				}
+			}
+			return nil
+		},
+	)
+
+	if err := botService.Start(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestBotService_Stop_Drain(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock Discord client
+	mockDiscordClient := mock.NewMockDiscordClient(ctrl)
 
 	// Create a mock logger
 	mockLogger := mock.NewMockLogger(ctrl)
 	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
 
-	// Create the bot service
-	botService := NewBotService(mockDiscordClient, mockOpenAIClient, mockLogger)
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
 
-	// Create a mock command handler
-	mockCommandHandler := mock.NewMockCommandHandler(ctrl)
+	// Create the handler, which is still running when the bot is stopped
+	started := make(chan struct{})
+	release := make(chan struct{})
+	finished := false
+	componentHandler := mock.NewMockCommandHandler(ctrl)
+	componentHandler.EXPECT().Handle(gomock.Any(), gomock.Any()).Do(func(s domain.Session, i *domain.InteractionCreate) {
+		close(started)
+		<-release
+		finished = true
+	})
 
-	// Register the command
-	botService.RegisterCommand("test", mockCommandHandler)
+	// Create the bot service
+	botService := NewBotService(mockDiscordClient, mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.RegisterComponent(CustomIDClue, componentHandler)
 
-	// Check that the command was registered
-	handler, ok := botService.commands["test"]
-	if !ok {
-		t.Error("Expected command to be registered")
+	component := &domain.InteractionCreate{
+		Type:      int(domain.InteractionMessageComponent),
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDClue},
 	}
-	if handler != mockCommandHandler {
-		t.Error("Expected registered handler to be the mock handler")
+	go botService.submit(mockSession, component)
+	<-started
+
+	// The gateway is closed only after the handler finished
+	mockDiscordClient.EXPECT().Stop().DoAndReturn(func() error {
+		if !finished {
+			t.Errorf("Expected the interaction in flight to finish before the gateway is closed")
+		}
+		return nil
+	})
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- botService.Stop()
+	}()
+
+	// The new interactions are told that the bot is restarting
+	mockSession.EXPECT().InteractionRespond(component, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != localizerFor(i).T(msgRestarting) || r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral restarting message, got %+v", r.Data)
+			}
+			return nil
+		})
+	for {
+		botService.mu.Lock()
+		stopping := botService.stopping
+		botService.mu.Unlock()
+		if stopping {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	botService.submit(mockSession, component)
+
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Errorf("Expected no error, got %v", err)
 	}
 }
+
+func TestBotService_Stop_Timeout(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock Discord client
+	mockDiscordClient := mock.NewMockDiscordClient(ctrl)
+	mockDiscordClient.EXPECT().Stop().Return(nil)
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+	// Create the bot service with an interaction which never finishes
+	botService := NewBotService(mockDiscordClient, mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.SetShutdownTimeout(10 * time.Millisecond)
+	botService.inflight.Add(1)
+
+	// The gateway is closed at the deadline anyway
+	if err := botService.Stop(); err != errShutdownTimeout {
+		t.Errorf("Expected the shutdown timeout, got %v", err)
+	}
+}
+
+func TestBotService_HandleMessage_Busy(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+	// Create the bot service with a dispatcher which rejects every task, since it is not started
+	botService := NewBotService(mock.NewMockDiscordClient(ctrl), mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.SetDispatcher(NewDispatcher(mockLogger))
+	botService.RegisterMessageHandler(mock.NewMockMessageHandler(ctrl))
+
+	// The dropped message is answered with the busy message
+	mockSession := mock.NewMockSession(ctrl)
+	mockSession.EXPECT().ReplyMessage("channel-id", "message-id", &domain.InteractionResponseData{Content: localizerFor(nil).T(msgBusy)}).Return(nil)
+
+	botService.handleMessage(mockSession, &domain.MessageCreate{ID: "message-id", ChannelID: "channel-id", Content: "質問"})
+}
@@ -1,55 +1,84 @@
 package usecase
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gong023/umi/domain"
 )
 
 type ClueCommandHandler struct {
 	openaiClient domain.OpenAIClient
+	fileSystem   domain.FileSystem
+	gameStore    domain.GameStore
+	leakGuard    *LeakGuard
 	logger       domain.Logger
 }
 
-func NewClueCommandHandler(openaiClient domain.OpenAIClient, logger domain.Logger) *ClueCommandHandler {
+func NewClueCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, gameStore domain.GameStore, leakGuard *LeakGuard, logger domain.Logger) *ClueCommandHandler {
 	return &ClueCommandHandler{
 		openaiClient: openaiClient,
+		fileSystem:   fileSystem,
+		gameStore:    gameStore,
+		leakGuard:    leakGuard,
 		logger:       logger,
 	}
 }
 
+func (h *ClueCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "clue",
+		Options: []*domain.ApplicationCommandOption{
+			{
+				// The private clue is shown only to the user who asked for it
+				Type: domain.ApplicationCommandOptionBoolean,
+				Name: "private",
+			},
+		},
+	})
+}
+
 func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling clue command")
-
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "ヒントを考えています...",
-		},
+	l := localizerFor(i)
+
+	// The private clues are tracked per user, so a clue without the user is given publicly
+	private, _ := boolOption(i, "private")
+	private = private && i.User != nil
+	userID := userIDOf(i)
+
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	// The private clue is deferred ephemerally, so that the clue is shown only to the user
+	deferred := deferResponse
+	if private {
+		deferred = deferEphemeralResponse
 	}
-
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	if err := deferred(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
 
 	// Check if a quiz exists
-	contextPath := filepath.Join("memo", "context.txt")
+	contextPath := h.fileSystem.JoinPath("memo", "context.txt")
 	quizExists := false
 	var contextContent []byte
 	var conversationHistory []string
 
 	// Check if the context file exists
-	if _, err := os.Stat(contextPath); err == nil {
+	exists, err := h.fileSystem.FileExists(contextPath)
+	if err != nil {
+		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+
+	if exists {
 		// Read the existing context file
-		contextContent, err = os.ReadFile(contextPath)
+		contextContent, err = h.fileSystem.ReadFile(contextPath)
 		if err != nil {
 			h.logger.Error("Failed to read context file: %v", err)
+			failResponse(s, i, h.logger)
 			return
 		}
 
@@ -64,11 +93,11 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	if !quizExists {
 		h.logger.Info("No quiz found")
 		// Send a response indicating that no quiz is available
-		followupMessage := "現在クイズが存在しません。`/create` コマンドで新しいクイズを作成してください。"
+		followupMessage := l.T(msgNoQuiz)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, followupMessage); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editResponse(s, i, followupMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		h.logger.Info("No quiz available, suggesting /create command: %s", followupMessage)
@@ -76,10 +105,11 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	}
 
 	// Read the prompt file
-	promptPath := filepath.Join("memo", "prompt", "onClue.txt")
-	promptContent, err := os.ReadFile(promptPath)
+	promptPath := h.fileSystem.JoinPath("memo", "prompt", "onClue.txt")
+	promptContent, err := h.fileSystem.ReadFile(promptPath)
 	if err != nil {
 		h.logger.Error("Failed to read prompt file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -91,6 +121,17 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 		},
 	}
 
+	// Give the stored solution to the model so that it is consistent with the other commands
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+	if solution, ok := solutionMessage(game, false); ok {
+		messages = append(messages, solution)
+	}
+
 	// Add the quiz (first line of the conversation history)
 	if len(conversationHistory) > 0 {
 		messages = append(messages, domain.ChatMessage{
@@ -99,6 +140,14 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 		})
 	}
 
+	// Add the clues the user has already seen, so that a new clue is given
+	for _, seen := range game.SeenClues(userID) {
+		messages = append(messages, domain.ChatMessage{
+			Role:    "assistant",
+			Content: seen.Clue,
+		})
+	}
+
 	// Add a request for a clue
 	messages = append(messages, domain.ChatMessage{
 		Role:    "user",
@@ -111,27 +160,42 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 		Temperature: 0.7,
 	}
 
-	// Send the request to the OpenAI API
-	h.logger.Info("Sending request to OpenAI API")
-	resp, err := h.openaiClient.CreateChatCompletion(req)
+	// Send the request to the OpenAI API through the leak guard, which checks the clue against
+	// each of the key points as well as the whole solution
+	secrets := append([]string{game.Solution}, game.KeyPoints...)
+	clue, ok, err := h.leakGuard.Complete(h.openaiClient, "clue", req, secrets...)
 	if err != nil {
 		h.logger.Error("Failed to create chat completion: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
-	// Extract the clue from the response
-	if len(resp.Choices) == 0 {
-		h.logger.Error("No choices in response")
+	if !ok {
+		h.logger.Info("Every clue leaked the solution, refusing the clue")
+		if err := editResponse(s, i, l.T(msgClueLeaked)); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
+		}
 		return
 	}
 
-	clue := resp.Choices[0].Message.Content
 	h.logger.Info("Received clue: %s", clue)
 
 	// Format the clue
-	formattedClue := fmt.Sprintf("**ヒント**: %s", strings.TrimSpace(clue))
+	formattedClue := l.T(msgClueTitle, strings.TrimSpace(clue))
+
+	// Track the clue, so that the clues seen by each user are known
+	game.Clues = append(game.Clues, &domain.ClueRecord{
+		Clue:    clue,
+		UserID:  userID,
+		Private: private,
+		GivenAt: time.Now(),
+	})
+	if err := h.gameStore.Save(game); err != nil {
+		h.logger.Error("Failed to save game record: %v", err)
+	}
 
-	// Append the clue to the context file
+	// Append the clue to the context file, the private clue as well, so that the context
+	// keeps every clue /info counts
 	// First, read the existing content
 	existingContent := ""
 	if len(contextContent) > 0 {
@@ -146,7 +210,7 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	updatedContent += clue
 
 	// Write the updated content back to the context file
-	if err := os.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
+	if err := h.fileSystem.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
 		h.logger.Error("Failed to update context file: %v", err)
 		// Continue with the response even if we fail to update the context file
 	} else {
@@ -154,8 +218,18 @@ func (h *ClueCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	}
 
 	// Send the response with the clue
-	if err := s.FollowupMessage(i, formattedClue); err != nil {
-		h.logger.Error("Failed to send follow-up message: %v", err)
+	if err := editResponse(s, i, formattedClue); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
+	}
+
+	if private {
+		// Note publicly that a private clue was used
+		if err := s.SendMessage(i.ChannelID, &domain.InteractionResponseData{Content: l.T(msgCluePrivateNote, i.User.Mention())}); err != nil {
+			h.logger.Error("Failed to send message: %v", err)
+		}
+
+		h.logger.Info("Private clue created for %s: %s", userID, formattedClue)
+		return
 	}
 
 	h.logger.Info("Clue created: %s", formattedClue)
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// commandDiff is the difference between the declared commands and the ones registered to Discord
+type commandDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+func (d *commandDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+func (d *commandDiff) String() string {
+	return "added: [" + strings.Join(d.Added, ", ") + "], changed: [" + strings.Join(d.Changed, ", ") + "], removed: [" + strings.Join(d.Removed, ", ") + "]"
+}
+
+// diffCommands compares the declared commands with the registered ones by name.
+// The DM permission is only compared for the global commands, since Discord
+// ignores it for the guild commands.
+func diffCommands(desired, registered []*domain.ApplicationCommand, global bool) *commandDiff {
+	diff := &commandDiff{}
+
+	current := make(map[string]*domain.ApplicationCommand, len(registered))
+	for _, cmd := range registered {
+		current[cmd.Name] = cmd
+	}
+
+	for _, cmd := range desired {
+		existing, ok := current[cmd.Name]
+		delete(current, cmd.Name)
+
+		if !ok {
+			diff.Added = append(diff.Added, cmd.Name)
+			continue
+		}
+		if !sameCommand(cmd, existing, global) {
+			diff.Changed = append(diff.Changed, cmd.Name)
+		}
+	}
+
+	for _, cmd := range registered {
+		if _, ok := current[cmd.Name]; ok {
+			diff.Removed = append(diff.Removed, cmd.Name)
+		}
+	}
+
+	return diff
+}
+
+func sameCommand(a, b *domain.ApplicationCommand, global bool) bool {
+	if a.Name != b.Name || a.Description != b.Description {
+		return false
+	}
+	if global && a.DMPermission != b.DMPermission {
+		return false
+	}
+	if !sameLocalizations(a.NameLocalizations, b.NameLocalizations) || !sameLocalizations(a.DescriptionLocalizations, b.DescriptionLocalizations) {
+		return false
+	}
+	if (a.DefaultMemberPermissions == nil) != (b.DefaultMemberPermissions == nil) {
+		return false
+	}
+	if a.DefaultMemberPermissions != nil && *a.DefaultMemberPermissions != *b.DefaultMemberPermissions {
+		return false
+	}
+
+	if len(a.Options) != len(b.Options) {
+		return false
+	}
+	for idx := range a.Options {
+		if !sameOption(a.Options[idx], b.Options[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameOption(a, b *domain.ApplicationCommandOption) bool {
+	if a.Type != b.Type || a.Name != b.Name || a.Description != b.Description || a.Required != b.Required {
+		return false
+	}
+	if !sameLocalizations(a.NameLocalizations, b.NameLocalizations) || !sameLocalizations(a.DescriptionLocalizations, b.DescriptionLocalizations) {
+		return false
+	}
+	if a.MaxLength != b.MaxLength || !reflect.DeepEqual(a.MinLength, b.MinLength) {
+		return false
+	}
+	if !reflect.DeepEqual(a.MinValue, b.MinValue) || !reflect.DeepEqual(a.MaxValue, b.MaxValue) {
+		return false
+	}
+
+	if len(a.Choices) != len(b.Choices) {
+		return false
+	}
+	for idx := range a.Choices {
+		if a.Choices[idx].Name != b.Choices[idx].Name || !sameChoiceValue(a.Choices[idx].Value, b.Choices[idx].Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameLocalizations treats a missing map and an empty map as the same
+func sameLocalizations(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for locale, text := range a {
+		if b[locale] != text {
+			return false
+		}
+	}
+	return true
+}
+
+// sameChoiceValue compares the values by their text, since the numbers come back from Discord as float64
+func sameChoiceValue(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// syncCommands registers the declared commands only when they differ from the
+// registered ones. The whole set is replaced in a single bulk overwrite, so the
+// commands stay available to the users while the bot restarts.
+func (s *BotService) syncCommands() error {
+	desired := s.definitions()
+
+	registered, err := s.discordClient.Commands(s.commandGuildID)
+	if err != nil {
+		return err
+	}
+
+	diff := diffCommands(desired, registered, s.commandGuildID == "")
+	if diff.Empty() {
+		s.logger.Info("Commands are up to date: %d commands", len(desired))
+		return nil
+	}
+
+	s.logger.Info("Syncing commands: %s", diff.String())
+	return s.discordClient.OverwriteCommands(s.commandGuildID, desired)
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDiffCommands(t *testing.T) {
+	desired := []*domain.ApplicationCommand{
+		{Name: "ping", Description: "Check if the bot is running", DMPermission: true},
+		{Name: "q", Description: "Ask a question", Options: []*domain.ApplicationCommandOption{
+			{Type: domain.ApplicationCommandOptionString, Name: "message", Description: "The question", Required: true},
+		}},
+		{Name: "help", Description: "Show the help"},
+	}
+	registered := []*domain.ApplicationCommand{
+		{Name: "ping", Description: "Check if the bot is running", DMPermission: true, DescriptionLocalizations: map[string]string{}},
+		{Name: "q", Description: "Ask a question", Options: []*domain.ApplicationCommandOption{
+			{Type: domain.ApplicationCommandOptionString, Name: "message", Description: "The question", Required: false},
+		}},
+		{Name: "quiz", Description: "Generate a quiz"},
+	}
+
+	diff := diffCommands(desired, registered, true)
+
+	if len(diff.Added) != 1 || diff.Added[0] != "help" {
+		t.Errorf("Expected help to be added, got %v", diff.Added)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0] != "q" {
+		t.Errorf("Expected q to be changed, got %v", diff.Changed)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "quiz" {
+		t.Errorf("Expected quiz to be removed, got %v", diff.Removed)
+	}
+}
+
+func TestDiffCommands_DMPermissionOnlyForGlobal(t *testing.T) {
+	desired := []*domain.ApplicationCommand{{Name: "q", Description: "Ask a question"}}
+	registered := []*domain.ApplicationCommand{{Name: "q", Description: "Ask a question", DMPermission: true}}
+
+	if diff := diffCommands(desired, registered, false); !diff.Empty() {
+		t.Errorf("Expected no difference for the guild commands, got %s", diff.String())
+	}
+	if diff := diffCommands(desired, registered, true); diff.Empty() {
+		t.Errorf("Expected a difference for the global commands")
+	}
+}
+
+func TestBotService_SyncCommands(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock Discord client
+	mockDiscordClient := mock.NewMockDiscordClient(ctrl)
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create the bot service registering the commands to the testing guild
+	botService := NewBotService(mockDiscordClient, mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.SetCommandGuild("test-guild-id")
+	botService.RegisterCommand(NewPingCommandHandler(mockLogger))
+
+	// Nothing is overwritten when the registered commands are up to date
+	mockDiscordClient.EXPECT().Commands("test-guild-id").Return(botService.definitions(), nil)
+	if err := botService.syncCommands(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// The whole set is overwritten when the commands differ
+	botService.RegisterCommand(NewHelpCommandHandler(mockLogger))
+	mockDiscordClient.EXPECT().Commands("test-guild-id").Return([]*domain.ApplicationCommand{NewPingCommandHandler(mockLogger).Definition()}, nil)
+	mockDiscordClient.EXPECT().OverwriteCommands("test-guild-id", gomock.Len(2)).Return(nil)
+	if err := botService.syncCommands(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// The error to get the registered commands is returned
+	mockDiscordClient.EXPECT().Commands("test-guild-id").Return(nil, errors.New("test error"))
+	if err := botService.syncCommands(); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"github.com/gong023/umi/domain"
+)
+
+// Custom IDs of the message components, used to route the interactions to their handlers
+const (
+	CustomIDClue          = "umi:clue"
+	CustomIDInfo          = "umi:info"
+	CustomIDGiveup        = "umi:giveup"
+	CustomIDGiveupConfirm = "umi:giveup:confirm"
+	CustomIDAnswer        = "umi:answer"
+	CustomIDAnswerModal   = "umi:answer:modal"
+	CustomIDCancel        = "umi:cancel"
+	CustomIDVoteYes       = "umi:vote:yes"
+	CustomIDVoteNo        = "umi:vote:no"
+)
+
+// gameButtons returns the buttons attached to the puzzle message
+func gameButtons(l *localizer) []*domain.ActionsRow {
+	return []*domain.ActionsRow{
+		{
+			Components: []domain.MessageComponent{
+				&domain.Button{Label: l.T(msgButtonClue), Style: domain.SecondaryButton, CustomID: CustomIDClue},
+				&domain.Button{Label: l.T(msgButtonInfo), Style: domain.SecondaryButton, CustomID: CustomIDInfo},
+				&domain.Button{Label: l.T(msgButtonGiveup), Style: domain.DangerButton, CustomID: CustomIDGiveup},
+				&domain.Button{Label: l.T(msgButtonAnswer), Style: domain.PrimaryButton, CustomID: CustomIDAnswer},
+			},
+		},
+	}
+}
+
+// ConfirmHandler asks the user to confirm a destructive action before it is run.
+// The confirmation is ephemeral, and its confirm button is routed by its custom ID
+// to the handler which actually runs the action.
+type ConfirmHandler struct {
+	prompt          messageKey
+	confirmLabel    messageKey
+	confirmCustomID string
+	logger          domain.Logger
+}
+
+func newConfirmHandler(prompt messageKey, confirmLabel messageKey, confirmCustomID string, logger domain.Logger) *ConfirmHandler {
+	return &ConfirmHandler{
+		prompt:          prompt,
+		confirmLabel:    confirmLabel,
+		confirmCustomID: confirmCustomID,
+		logger:          logger,
+	}
+}
+
+// NewGiveupConfirmHandler asks for the confirmation before giving up from the button
+func NewGiveupConfirmHandler(logger domain.Logger) *ConfirmHandler {
+	return newConfirmHandler(msgGiveupConfirm, msgGiveupConfirmButton, CustomIDGiveupConfirm, logger)
+}
+
+func (h *ConfirmHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
+	h.logger.Info("Asking for confirmation: %s", h.confirmCustomID)
+	l := localizerFor(i)
+
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseChannelMessageWithSource),
+		Data: &domain.InteractionResponseData{
+			Content: l.T(h.prompt),
+			Flags:   domain.MessageFlagsEphemeral,
+			Components: []*domain.ActionsRow{
+				{
+					Components: []domain.MessageComponent{
+						&domain.Button{Label: l.T(h.confirmLabel), Style: domain.DangerButton, CustomID: h.confirmCustomID},
+						&domain.Button{Label: l.T(msgButtonCancel), Style: domain.SecondaryButton, CustomID: CustomIDCancel},
+					},
+				},
+			},
+		},
+	}
+
+	if err := s.InteractionRespond(i, response); err != nil {
+		h.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// CancelHandler closes a confirmation without running the action
+type CancelHandler struct {
+	logger domain.Logger
+}
+
+func NewCancelHandler(logger domain.Logger) *CancelHandler {
+	return &CancelHandler{
+		logger: logger,
+	}
+}
+
+func (h *CancelHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
+	h.logger.Info("Cancelling confirmation")
+
+	// Replace the confirmation so that its buttons can not be pressed again
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseUpdateMessage),
+		Data: &domain.InteractionResponseData{
+			Content:    localizerFor(i).T(msgCancelled),
+			Components: []*domain.ActionsRow{},
+		},
+	}
+
+	if err := s.InteractionRespond(i, response); err != nil {
+		h.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// answerInputCustomID is the custom ID of the text input in the answer modal
+const answerInputCustomID = "answer"
+
+// opensAnswerModal returns true for /answer without the answer, which only opens the modal
+func opensAnswerModal(i *domain.InteractionCreate) bool {
+	return i.Data != nil && i.Data.Name == "answer" && stringOption(i, "message") == ""
+}
+
+// answerModal opens the modal to write a long answer in multiple lines
+// The submission is routed to AnswerCommandHandler by CustomIDAnswerModal
+func answerModal(l *localizer) *domain.InteractionResponse {
+	return &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseModal),
+		Data: &domain.InteractionResponseData{
+			CustomID: CustomIDAnswerModal,
+			Title:    l.T(msgAnswerModalTitle),
+			Components: []*domain.ActionsRow{
+				{
+					Components: []domain.MessageComponent{
+						&domain.TextInput{
+							CustomID:    answerInputCustomID,
+							Label:       l.T(msgAnswerModalLabel),
+							Style:       domain.TextInputParagraph,
+							Placeholder: l.T(msgAnswerModalPlaceholder),
+							Required:    true,
+							MaxLength:   1000,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConfirmHandler_Handle(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	interaction := &domain.InteractionCreate{
+		Type:      int(domain.InteractionMessageComponent),
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDGiveup},
+	}
+
+	// The confirmation is ephemeral and carries the confirm and cancel buttons
+	mockSession.EXPECT().InteractionRespond(interaction, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral confirmation")
+			}
+			if len(r.Data.Components) != 1 || len(r.Data.Components[0].Components) != 2 {
+				t.Fatalf("Expected a row of two buttons, got %+v", r.Data.Components)
+			}
+			confirm := r.Data.Components[0].Components[0].(*domain.Button)
+			if confirm.CustomID != CustomIDGiveupConfirm {
+				t.Errorf("Expected the confirm button to be routed to %s, got %s", CustomIDGiveupConfirm, confirm.CustomID)
+			}
+			cancel := r.Data.Components[0].Components[1].(*domain.Button)
+			if cancel.CustomID != CustomIDCancel {
+				t.Errorf("Expected the cancel button to be routed to %s, got %s", CustomIDCancel, cancel.CustomID)
+			}
+			return nil
+		},
+	)
+
+	handler := NewGiveupConfirmHandler(mockLogger)
+	handler.Handle(mockSession, interaction)
+}
+
+func TestCancelHandler_Handle(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	interaction := &domain.InteractionCreate{
+		Type:      int(domain.InteractionMessageComponent),
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDCancel},
+	}
+
+	// The confirmation is updated in place and its buttons are removed
+	mockSession.EXPECT().InteractionRespond(interaction, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Type != int(domain.InteractionResponseUpdateMessage) {
+				t.Errorf("Expected the message to be updated, got type %d", r.Type)
+			}
+			if r.Data.Components == nil || len(r.Data.Components) != 0 {
+				t.Errorf("Expected the buttons to be removed, got %+v", r.Data.Components)
+			}
+			return nil
+		},
+	)
+
+	handler := NewCancelHandler(mockLogger)
+	handler.Handle(mockSession, interaction)
+}
+
+func TestGameButtons(t *testing.T) {
+	rows := gameButtons(localizerFor(nil))
+	if len(rows) != 1 {
+		t.Fatalf("Expected a row of buttons, got %d rows", len(rows))
+	}
+
+	expected := []string{CustomIDClue, CustomIDInfo, CustomIDGiveup, CustomIDAnswer}
+	if len(rows[0].Components) != len(expected) {
+		t.Fatalf("Expected %d buttons, got %d", len(expected), len(rows[0].Components))
+	}
+	for idx, component := range rows[0].Components {
+		button := component.(*domain.Button)
+		if button.CustomID != expected[idx] {
+			t.Errorf("Expected button %d to be routed to %s, got %s", idx, expected[idx], button.CustomID)
+		}
+	}
+}
@@ -1,39 +1,52 @@
 package usecase
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/gong023/umi/domain"
 )
 
 type CreateCommandHandler struct {
-	openaiClient domain.OpenAIClient
-	fileSystem   domain.FileSystem
-	logger       domain.Logger
+	openaiClient  domain.OpenAIClient
+	fileSystem    domain.FileSystem
+	gameStore     domain.GameStore
+	logger        domain.Logger
+	threadPerGame bool
 }
 
-func NewCreateCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, logger domain.Logger) *CreateCommandHandler {
+func NewCreateCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, gameStore domain.GameStore, logger domain.Logger) *CreateCommandHandler {
 	return &CreateCommandHandler{
 		openaiClient: openaiClient,
 		fileSystem:   fileSystem,
+		gameStore:    gameStore,
 		logger:       logger,
 	}
 }
 
-func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
-	h.logger.Info("Handling create command")
+// SetThreadPerGame makes /create play the quiz in a new thread unless the thread option says otherwise
+func (h *CreateCommandHandler) SetThreadPerGame(enabled bool) {
+	h.threadPerGame = enabled
+}
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "クイズを確認しています...",
+func (h *CreateCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "create",
+		Options: []*domain.ApplicationCommandOption{
+			{
+				Type: domain.ApplicationCommandOptionBoolean,
+				Name: "thread",
+			},
 		},
-	}
+	})
+}
 
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
+	h.logger.Info("Handling create command")
+	l := localizerFor(i)
+
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
@@ -47,6 +60,7 @@ func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	exists, err := h.fileSystem.FileExists(contextPath)
 	if err != nil {
 		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -55,6 +69,7 @@ func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		contextContent, err := h.fileSystem.ReadFile(contextPath)
 		if err != nil {
 			h.logger.Error("Failed to read context file: %v", err)
+			failResponse(s, i, h.logger)
 			return
 		}
 
@@ -69,15 +84,15 @@ func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	if quizExists {
 		h.logger.Info("Quiz already exists")
 
-		// Format the response with the existing quiz and introduce the /quit command
-		formattedResponse := fmt.Sprintf("**現在のウミガメのスープクイズ**\n\n%s\n\n現在のクイズを終了するには `/quit` コマンドを使用してください。", strings.TrimSpace(existingQuiz))
+		// Show the existing quiz as a puzzle card and introduce the /quit command
+		embed := puzzleEmbed(l.T(msgPuzzleCurrentTitle), strings.TrimSpace(existingQuiz), l.T(msgPuzzleCurrentFooter))
 
 		// Send the response with the existing quiz
-		if err := s.FollowupMessage(i, formattedResponse); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editEmbed(s, i, embed, gameButtons(l)...); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
-		h.logger.Info("Returning existing quiz: %s", formattedResponse)
+		h.logger.Info("Returning existing quiz: %s", embed.Description)
 		return
 	}
 
@@ -89,6 +104,7 @@ func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	promptContent, err := h.fileSystem.ReadFile(promptPath)
 	if err != nil {
 		h.logger.Error("Failed to read prompt file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -105,7 +121,8 @@ func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 				Content: "新しいウミガメのスープクイズを考えてください。",
 			},
 		},
-		Temperature: 0.7,
+		Temperature:    0.7,
+		ResponseFormat: &domain.ResponseFormat{Type: "json_object"},
 	}
 
 	// Send the request to the OpenAI API
@@ -113,33 +130,85 @@ func (h *CreateCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	resp, err := h.openaiClient.CreateChatCompletion(req)
 	if err != nil {
 		h.logger.Error("Failed to create chat completion: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
 	// Extract the quiz from the response
 	if len(resp.Choices) == 0 {
 		h.logger.Error("No choices in response")
+		failResponse(s, i, h.logger)
 		return
 	}
 
-	quiz := resp.Choices[0].Message.Content
+	// Parse the puzzle and its solution from the response
+	generated, err := parseGeneratedQuiz(resp.Choices[0].Message.Content)
+	if err != nil {
+		h.logger.Error("Failed to parse generated quiz: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+
+	quiz := generated.Puzzle
 	h.logger.Info("Received quiz: %s", quiz)
 
+	// Save the solution hidden from the players before the quiz is published
+	game := &domain.Game{
+		Solution:  generated.Solution,
+		KeyPoints: generated.KeyPoints,
+		ChannelID: i.ChannelID,
+	}
+	if i.User != nil {
+		game.CreatorID = i.User.ID
+	}
+	if err := h.gameStore.Save(game); err != nil {
+		h.logger.Error("Failed to save game record: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+
 	// Save the quiz to the context file
 	if err := h.fileSystem.WriteFile(contextPath, []byte(quiz), 0644); err != nil {
 		h.logger.Error("Failed to write quiz to context file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
 	h.logger.Info("Saved quiz to context file: %s", contextPath)
 
-	// Format the quiz
-	formattedQuiz := fmt.Sprintf("**新しいウミガメのスープクイズ**\n\n%s", strings.TrimSpace(quiz))
+	// Show the quiz as a puzzle card
+	embed := puzzleEmbed(l.T(msgPuzzleNewTitle), strings.TrimSpace(quiz), l.T(msgPuzzleNewFooter))
+
+	// Play the quiz in a new thread when the thread mode is on
+	threadPerGame := h.threadPerGame
+	if enabled, ok := boolOption(i, "thread"); ok {
+		threadPerGame = enabled
+	}
+	if threadPerGame {
+		threadID, err := startGameThread(s, i, embed, gameButtons(l))
+		if err == nil {
+			// Bind the game to the thread so that it is closed when the game ends
+			game.ThreadID = threadID
+			if err := h.gameStore.Save(game); err != nil {
+				h.logger.Error("Failed to save game record: %v", err)
+			}
+
+			if err := editResponse(s, i, l.T(msgThreadStarted, threadID)); err != nil {
+				h.logger.Error("Failed to edit response: %v", err)
+			}
+
+			h.logger.Info("Quiz created in thread %s: %s", threadID, embed.Description)
+			return
+		}
+
+		// Fall back to the channel when the thread can not be started
+		h.logger.Error("Failed to start the thread of the game: %v", err)
+	}
 
-	// Send the response with the new quiz
-	if err := s.FollowupMessage(i, formattedQuiz); err != nil {
-		h.logger.Error("Failed to send follow-up message: %v", err)
+	// Send the response with the new quiz and the buttons to play it
+	if err := editEmbed(s, i, embed, gameButtons(l)...); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
 	}
 
-	h.logger.Info("Quiz created: %s", formattedQuiz)
+	h.logger.Info("Quiz created: %s", embed.Description)
 }
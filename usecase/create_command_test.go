@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/gong023/umi/domain"
@@ -38,7 +39,7 @@ func TestCreateCommandHandler_Handle_NewQuiz(t *testing.T) {
 
 	// Set up expectations for the session
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil)
-	mockSession.EXPECT().FollowupMessage(gomock.Any(), gomock.Any()).Return(nil)
+	mockSession.EXPECT().InteractionResponseEdit(gomock.Any(), gomock.Any()).Return(nil)
 
 	// Set up the file system mock
 	contextPath := "memo/context.txt"
@@ -56,6 +57,8 @@ func TestCreateCommandHandler_Handle_NewQuiz(t *testing.T) {
 	mockFileSystem.EXPECT().JoinPath("memo", "prompt", "oncreate.txt").Return(promptPath).AnyTimes()
 
 	// Set up OpenAI mock
+	puzzle := "男性が海辺で亀のスープを飲んでいました。彼は一口飲んだ後、自殺しました。なぜでしょうか？"
+	solution := "男性は遭難した時に仲間の肉を亀のスープだと騙されて食べていた。本物の亀のスープを飲んでそれに気付いた。"
 	mockResponse := &domain.ChatCompletionResponse{
 		ID:      "test-response-id",
 		Object:  "chat.completion",
@@ -69,7 +72,7 @@ func TestCreateCommandHandler_Handle_NewQuiz(t *testing.T) {
 				Index: 0,
 				Message: domain.ChatMessage{
 					Role:    "assistant",
-					Content: "男性が海辺で亀のスープを飲んでいました。彼は一口飲んだ後、自殺しました。なぜでしょうか？",
+					Content: `{"puzzle": "` + puzzle + `", "solution": "` + solution + `", "key_points": ["男性は遭難したことがある", "仲間の肉を食べていた"]}`,
 				},
 				FinishReason: "stop",
 			},
@@ -78,8 +81,22 @@ func TestCreateCommandHandler_Handle_NewQuiz(t *testing.T) {
 
 	mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).Return(mockResponse, nil)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
+	// The solution is saved in the game record, hidden from the players
+	mockGameStore.EXPECT().Save(gomock.Any()).DoAndReturn(func(game *domain.Game) error {
+		if game.Solution != solution {
+			t.Errorf("Expected solution to be saved as '%s', but got '%s'", solution, game.Solution)
+		}
+		if len(game.KeyPoints) != 2 {
+			t.Errorf("Expected 2 key points to be saved, but got %d", len(game.KeyPoints))
+		}
+		return nil
+	})
+
 	// Create the create command handler
-	handler := NewCreateCommandHandler(mockOpenAIClient, mockFileSystem, mockLogger)
+	handler := NewCreateCommandHandler(mockOpenAIClient, mockFileSystem, mockGameStore, mockLogger)
 
 	// Capture the data written to the file
 	var savedQuizData []byte
@@ -92,10 +109,10 @@ func TestCreateCommandHandler_Handle_NewQuiz(t *testing.T) {
 	// Handle the interaction
 	handler.Handle(mockSession, interaction)
 
-	// Verify that the quiz was saved correctly
-	if string(savedQuizData) != mockResponse.Choices[0].Message.Content {
+	// Verify that only the puzzle was saved to the context file
+	if string(savedQuizData) != puzzle {
 		t.Errorf("Expected quiz to be saved as '%s', but got '%s'",
-			mockResponse.Choices[0].Message.Content, string(savedQuizData))
+			puzzle, string(savedQuizData))
 	}
 }
 
@@ -129,7 +146,7 @@ func TestCreateCommandHandler_Handle_ExistingQuiz(t *testing.T) {
 
 	// Set up expectations for the session
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil)
-	mockSession.EXPECT().FollowupMessage(gomock.Any(), gomock.Any()).Return(nil)
+	mockSession.EXPECT().InteractionResponseEdit(gomock.Any(), gomock.Any()).Return(nil)
 
 	// Set up the file system mock
 	contextPath := "memo/context.txt"
@@ -144,11 +161,76 @@ func TestCreateCommandHandler_Handle_ExistingQuiz(t *testing.T) {
 	// Mock path joining
 	mockFileSystem.EXPECT().JoinPath("memo", "context.txt").Return(contextPath).AnyTimes()
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the create command handler
-	handler := NewCreateCommandHandler(mockOpenAIClient, mockFileSystem, mockLogger)
+	handler := NewCreateCommandHandler(mockOpenAIClient, mockFileSystem, mockGameStore, mockLogger)
 
 	// Handle the interaction
 	handler.Handle(mockSession, interaction)
 
 	// No need to verify OpenAI calls since it shouldn't be called when a quiz already exists
 }
+
+func TestCreateCommandHandler_Handle_DeferredFailure(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock OpenAI client
+	mockOpenAIClient := mock.NewMockOpenAIClient(ctrl)
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock file system
+	mockFileSystem := mock.NewMockFileSystem(ctrl)
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create a mock interaction
+	interaction := &domain.InteractionCreate{
+		ID:   "test-interaction-id",
+		Type: 2, // APPLICATION_COMMAND
+		Data: &domain.ApplicationCommandInteractionData{
+			Name: "create",
+		},
+	}
+
+	// The command is deferred first, and the deferred response is edited into the failure message
+	gomock.InOrder(
+		mockSession.EXPECT().InteractionRespond(interaction, gomock.Any()).DoAndReturn(
+			func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+				if r.Type != int(domain.InteractionResponseDeferredChannelMessageWithSource) {
+					t.Errorf("Expected deferred response, got type %d", r.Type)
+				}
+				return nil
+			},
+		),
+		mockSession.EXPECT().InteractionResponseEdit(interaction, gomock.Any()).DoAndReturn(
+			func(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+				if data.Content != localizerFor(i).T(msgFailure) {
+					t.Errorf("Expected failure message, got %q", data.Content)
+				}
+				return nil
+			},
+		),
+	)
+
+	// Mock file existence check failure
+	mockFileSystem.EXPECT().JoinPath("memo", "context.txt").Return("memo/context.txt").AnyTimes()
+	mockFileSystem.EXPECT().FileExists("memo/context.txt").Return(false, errors.New("disk error"))
+
+	// Create the handler
+	handler := NewCreateCommandHandler(mockOpenAIClient, mockFileSystem, mockGameStore, mockLogger)
+
+	// Call the handler
+	handler.Handle(mockSession, interaction)
+}
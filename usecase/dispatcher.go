@@ -0,0 +1,304 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gong023/umi/domain"
+)
+
+const (
+	// defaultDispatchWorkers is the number of the interactions handled at the same time
+	defaultDispatchWorkers = 4
+
+	// defaultDispatchQueue is the number of the interactions waiting or running before the new ones are rejected
+	defaultDispatchQueue = 64
+
+	// gameQueueKey serializes the interactions on the game, since the game record is shared by the bot
+	gameQueueKey = "game"
+)
+
+// statelessCommands are the commands which do not touch the game, so that they run in parallel with anything
+var statelessCommands = map[string]bool{
+	"ping": true,
+	"help": true,
+}
+
+// statelessComponents are the buttons which only open a modal or a confirmation, which
+// have to be the initial response and so should not wait behind the game
+var statelessComponents = map[string]bool{
+	CustomIDAnswer: true,
+	CustomIDGiveup: true,
+	CustomIDCancel: true,
+}
+
+// dispatchKey returns the key the interaction is serialized by, or empty when it can run in parallel.
+// The bot keeps a single game in memo, so every interaction on the game shares the same key.
+func dispatchKey(i *domain.InteractionCreate) string {
+	if i.Data != nil {
+		if statelessCommands[i.Data.Name] {
+			return ""
+		}
+
+		// /answer without the answer only opens the modal
+		if opensAnswerModal(i) {
+			return ""
+		}
+	}
+	if i.Component != nil && statelessComponents[i.Component.CustomID] {
+		return ""
+	}
+	return gameQueueKey
+}
+
+// DispatcherStats is the metrics of the dispatcher
+type DispatcherStats struct {
+	// Queued is the number of the tasks waiting for a worker
+	Queued int
+
+	// Running is the number of the tasks being run
+	Running int
+
+	// Processed is the number of the tasks finished
+	Processed int
+
+	// Rejected is the number of the tasks rejected because the queue was full
+	Rejected int
+
+	// TotalWait and MaxWait are how long the finished tasks waited for a worker
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// AverageWait returns the average time the finished tasks waited for a worker
+func (s DispatcherStats) AverageWait() time.Duration {
+	if s.Processed == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Processed)
+}
+
+// dispatchTask is a task waiting in the queue
+type dispatchTask struct {
+	run        func()
+	enqueuedAt time.Time
+}
+
+// dispatchQueue is the tasks of a key, which are run one by one in the order of their arrival
+type dispatchQueue struct {
+	key   string
+	tasks []*dispatchTask
+}
+
+// Dispatcher runs the interactions on a bounded pool of workers, so that the
+// event goroutines of discordgo are not blocked by the OpenAI API calls. The
+// tasks with the same key are run one at a time in the order of their arrival,
+// while the tasks with the other keys run in parallel. The new tasks are
+// rejected once too many tasks are waiting or running.
+type Dispatcher struct {
+	logger    domain.Logger
+	workers   int
+	queueSize int
+
+	// queues are the tasks by their key, which are removed once all the tasks of the key are done
+	queues map[string]*dispatchQueue
+
+	// ready are the queues with a task to run and no task running
+	ready chan *dispatchQueue
+
+	// pending is the number of the tasks waiting or running
+	pending int
+
+	stats   DispatcherStats
+	started bool
+	wg      sync.WaitGroup
+
+	// statsInterval is how often the metrics are logged, or zero not to log them
+	statsInterval time.Duration
+
+	// stopStats stops logging the metrics
+	stopStats chan struct{}
+
+	// now is replaced in the tests
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+func NewDispatcher(logger domain.Logger) *Dispatcher {
+	return &Dispatcher{
+		logger:    logger,
+		workers:   defaultDispatchWorkers,
+		queueSize: defaultDispatchQueue,
+		queues:    make(map[string]*dispatchQueue),
+		now:       time.Now,
+	}
+}
+
+// SetWorkers sets the number of the tasks run at the same time
+func (d *Dispatcher) SetWorkers(workers int) {
+	d.workers = workers
+}
+
+// SetQueueSize sets the number of the tasks waiting or running before the new ones are rejected
+func (d *Dispatcher) SetQueueSize(size int) {
+	d.queueSize = size
+}
+
+// SetStatsInterval logs the metrics at the interval while the dispatcher is running
+func (d *Dispatcher) SetStatsInterval(interval time.Duration) {
+	d.statsInterval = interval
+}
+
+// Start starts the workers
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.started {
+		return
+	}
+	d.started = true
+
+	// Every key in the channel has a task pending, so sending to it never blocks
+	d.ready = make(chan *dispatchQueue, d.queueSize)
+
+	d.logger.Info("Starting dispatcher with %d workers and %d queue", d.workers, d.queueSize)
+	for n := 0; n < d.workers; n++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+
+	if d.statsInterval > 0 {
+		d.stopStats = make(chan struct{})
+		go d.logStats(d.statsInterval, d.stopStats)
+	}
+}
+
+// Stop stops the workers after the tasks already submitted are done
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if !d.started {
+		d.mu.Unlock()
+		return
+	}
+	d.started = false
+
+	// The last task to finish closes the channel otherwise, since its key may be sent to it again
+	if d.pending == 0 {
+		close(d.ready)
+	}
+	if d.stopStats != nil {
+		close(d.stopStats)
+		d.stopStats = nil
+	}
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	d.logger.Info("Stopped dispatcher")
+}
+
+// Submit queues the task under the key, and returns false when the queue is
+// full or the dispatcher is not running. The task with an empty key is not
+// serialized with any other task.
+func (d *Dispatcher) Submit(key string, run func()) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.started || d.pending >= d.queueSize {
+		d.stats.Rejected++
+		d.logger.Info("Rejected task key=%s pending=%d", key, d.pending)
+		return false
+	}
+	d.pending++
+	d.stats.Queued++
+
+	task := &dispatchTask{run: run, enqueuedAt: d.now()}
+
+	// Wait behind the tasks of the same key
+	if queue, ok := d.queues[key]; ok && key != "" {
+		queue.tasks = append(queue.tasks, task)
+		return true
+	}
+
+	queue := &dispatchQueue{key: key, tasks: []*dispatchTask{task}}
+	if key != "" {
+		d.queues[key] = queue
+	}
+	d.ready <- queue
+
+	return true
+}
+
+// Stats returns the metrics so far
+func (d *Dispatcher) Stats() DispatcherStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.stats
+}
+
+// logStats logs the metrics at the interval until it is stopped
+func (d *Dispatcher) logStats(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			stats := d.Stats()
+			d.logger.Info("Dispatcher stats: queued=%d running=%d processed=%d rejected=%d average_wait=%s max_wait=%s",
+				stats.Queued, stats.Running, stats.Processed, stats.Rejected, stats.AverageWait(), stats.MaxWait)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// work runs the tasks of the ready queues until the dispatcher is stopped
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for queue := range d.ready {
+		d.mu.Lock()
+		task := queue.tasks[0]
+		queue.tasks = queue.tasks[1:]
+		wait := d.now().Sub(task.enqueuedAt)
+		d.stats.Queued--
+		d.stats.Running++
+		d.mu.Unlock()
+
+		d.run(task)
+
+		d.mu.Lock()
+		d.pending--
+		d.stats.Running--
+		d.stats.Processed++
+		d.stats.TotalWait += wait
+		if wait > d.stats.MaxWait {
+			d.stats.MaxWait = wait
+		}
+
+		// Let the next task of the key run, behind the queues already ready
+		if len(queue.tasks) > 0 {
+			d.ready <- queue
+		} else if queue.key != "" {
+			delete(d.queues, queue.key)
+		}
+		if !d.started && d.pending == 0 {
+			close(d.ready)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// run runs the task, and keeps the worker alive when the task panics
+func (d *Dispatcher) run(task *dispatchTask) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			d.logger.Error("Recovered from panic in dispatched task: %v", recovered)
+		}
+	}()
+
+	task.run()
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func newTestDispatcher(ctrl *gomock.Controller, workers, queueSize int) *Dispatcher {
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	dispatcher := NewDispatcher(mockLogger)
+	dispatcher.SetWorkers(workers)
+	dispatcher.SetQueueSize(queueSize)
+	return dispatcher
+}
+
+func TestDispatchKey(t *testing.T) {
+	if key := dispatchKey(&domain.InteractionCreate{Data: &domain.ApplicationCommandInteractionData{Name: "ping"}}); key != "" {
+		t.Errorf("Expected /ping not to be serialized, got %s", key)
+	}
+	if key := dispatchKey(&domain.InteractionCreate{Data: &domain.ApplicationCommandInteractionData{Name: "q"}}); key != gameQueueKey {
+		t.Errorf("Expected /q to be serialized on the game, got %s", key)
+	}
+	if key := dispatchKey(&domain.InteractionCreate{Component: &domain.MessageComponentInteractionData{CustomID: CustomIDClue}}); key != gameQueueKey {
+		t.Errorf("Expected the buttons to be serialized on the game, got %s", key)
+	}
+
+	// The modal and the confirmation are opened without waiting behind the game
+	if key := dispatchKey(&domain.InteractionCreate{Data: &domain.ApplicationCommandInteractionData{Name: "answer"}}); key != "" {
+		t.Errorf("Expected /answer opening the modal not to be serialized, got %s", key)
+	}
+	if key := dispatchKey(&domain.InteractionCreate{Component: &domain.MessageComponentInteractionData{CustomID: CustomIDAnswer}}); key != "" {
+		t.Errorf("Expected the answer button not to be serialized, got %s", key)
+	}
+}
+
+func TestDispatcher_SameKeyInOrder(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dispatcher := newTestDispatcher(ctrl, 4, 16)
+	dispatcher.Start()
+
+	var mu sync.Mutex
+	var order []int
+	running := 0
+	for n := 0; n < 8; n++ {
+		n := n
+		dispatcher.Submit("game", func() {
+			mu.Lock()
+			running++
+			if running > 1 {
+				t.Errorf("Expected the tasks of the same key to run one at a time")
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			order = append(order, n)
+			mu.Unlock()
+		})
+	}
+
+	// Stop waits for the tasks already submitted
+	dispatcher.Stop()
+
+	if len(order) != 8 {
+		t.Fatalf("Expected 8 tasks to run, got %d", len(order))
+	}
+	for n, got := range order {
+		if got != n {
+			t.Errorf("Expected the tasks in the order of their arrival, got %v", order)
+			break
+		}
+	}
+
+	stats := dispatcher.Stats()
+	if stats.Processed != 8 || stats.Queued != 0 || stats.Running != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestDispatcher_OtherKeysInParallel(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dispatcher := newTestDispatcher(ctrl, 2, 16)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	// The task of the game blocks until the stateless task runs beside it
+	release := make(chan struct{})
+	done := make(chan struct{})
+	dispatcher.Submit("game", func() {
+		<-release
+		close(done)
+	})
+	dispatcher.Submit("", func() {
+		close(release)
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the tasks of the other keys to run in parallel")
+	}
+}
+
+func TestDispatcher_Backpressure(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dispatcher := newTestDispatcher(ctrl, 1, 2)
+
+	// Nothing is accepted before the start
+	if dispatcher.Submit("game", func() {}) {
+		t.Errorf("Expected the task to be rejected before the start")
+	}
+
+	dispatcher.Start()
+
+	release := make(chan struct{})
+	for n := 0; n < 2; n++ {
+		if !dispatcher.Submit("game", func() { <-release }) {
+			t.Fatalf("Expected the task %d to be accepted", n+1)
+		}
+	}
+
+	// The queue is full while the tasks are waiting or running
+	if dispatcher.Submit("", func() {}) {
+		t.Errorf("Expected the task to be rejected when the queue is full")
+	}
+
+	close(release)
+	dispatcher.Stop()
+
+	stats := dispatcher.Stats()
+	if stats.Processed != 2 || stats.Rejected != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestDispatcher_Panic(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dispatcher := newTestDispatcher(ctrl, 1, 4)
+	dispatcher.Start()
+
+	// The worker survives the panic and runs the next task of the key
+	ran := false
+	dispatcher.Submit("game", func() { panic("boom") })
+	dispatcher.Submit("game", func() { ran = true })
+	dispatcher.Stop()
+
+	if !ran {
+		t.Errorf("Expected the task after the panic to run")
+	}
+}
+
+func TestDispatcher_StatsInterval(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger, which receives the metrics
+	logged := make(chan struct{}, 1)
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).DoAndReturn(func(format string, args ...interface{}) {
+		if strings.HasPrefix(format, "Dispatcher stats") {
+			select {
+			case logged <- struct{}{}:
+			default:
+			}
+		}
+	}).AnyTimes()
+
+	dispatcher := NewDispatcher(mockLogger)
+	dispatcher.SetStatsInterval(10 * time.Millisecond)
+	dispatcher.Start()
+	defer dispatcher.Stop()
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the metrics to be logged")
+	}
+}
+
+func TestBotService_Submit_Busy(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create the bot service with the dispatcher not running
+	botService := NewBotService(mock.NewMockDiscordClient(ctrl), mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.SetDispatcher(newTestDispatcher(ctrl, 1, 1))
+
+	// The rejected interaction is told ephemerally to try again
+	i := &domain.InteractionCreate{
+		Type: int(domain.InteractionApplicationCommand),
+		Data: &domain.ApplicationCommandInteractionData{Name: "q"},
+	}
+	mockSession.EXPECT().InteractionRespond(i, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != localizerFor(i).T(msgBusy) || r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral busy message, got %+v", r.Data)
+			}
+			return nil
+		})
+	botService.submit(mockSession, i)
+}
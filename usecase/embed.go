@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"unicode/utf8"
+
+	"github.com/gong023/umi/domain"
+)
+
+// Colours of the embeds, so that every command shares the same visual language
+const (
+	colorPuzzle    = 0x3498DB
+	colorCorrect   = 0x2ECC71
+	colorIncorrect = 0xE74C3C
+	colorClose     = 0xF1C40F
+	colorNeutral   = 0x95A5A6
+	colorSolution  = 0x9B59B6
+)
+
+// Discord rejects embeds whose texts exceed these lengths
+const (
+	maxEmbedDescription = 4096
+	maxEmbedFieldValue  = 1024
+)
+
+// puzzleEmbed is the card which presents the puzzle
+func puzzleEmbed(title string, puzzle string, footer string) *domain.MessageEmbed {
+	return &domain.MessageEmbed{
+		Title:       "🐢 " + title,
+		Description: truncateRunes(puzzle, maxEmbedDescription),
+		Color:       colorPuzzle,
+		Footer:      &domain.MessageEmbedFooter{Text: footer},
+	}
+}
+
+// qAnswerEmbed is the card which pairs the question with its answer
+func qAnswerEmbed(l *localizer, question string, answer *parsedQAnswer) *domain.MessageEmbed {
+	embed := qEmbed(l, question, answer.Render(l), qAnswerColor(answer.Answer))
+	if answer.Answer == domain.QAnswerImportantYes {
+		embed.Footer = &domain.MessageEmbedFooter{Text: l.T(msgQImportant)}
+	}
+	return embed
+}
+
+// qRefusalEmbed is the card shown when the question could not be answered
+func qRefusalEmbed(l *localizer, question string, refusal string) *domain.MessageEmbed {
+	return qEmbed(l, question, refusal, colorNeutral)
+}
+
+func qEmbed(l *localizer, question string, answer string, color int) *domain.MessageEmbed {
+	return &domain.MessageEmbed{
+		Title: l.T(msgQTitle),
+		Color: color,
+		Fields: []*domain.MessageEmbedField{
+			{Name: l.T(msgQField), Value: truncateRunes(question, maxEmbedFieldValue)},
+			{Name: l.T(msgAnswerField), Value: truncateRunes(answer, maxEmbedFieldValue)},
+		},
+	}
+}
+
+func qAnswerColor(answer domain.QAnswer) int {
+	switch answer {
+	case domain.QAnswerYes, domain.QAnswerImportantYes:
+		return colorCorrect
+	case domain.QAnswerNo:
+		return colorIncorrect
+	case domain.QAnswerPartially:
+		return colorClose
+	default:
+		return colorNeutral
+	}
+}
+
+// verdictEmbed is the card colour-coded by the verdict of the answer
+func verdictEmbed(l *localizer, answer string, result *judgeResult) *domain.MessageEmbed {
+	color := colorIncorrect
+	switch result.Verdict {
+	case verdictCorrect:
+		color = colorCorrect
+	case verdictClose:
+		color = colorClose
+	}
+
+	embed := &domain.MessageEmbed{
+		Title:       l.T(msgVerdictTitle, l.T(verdictKey(result.Verdict))),
+		Description: truncateRunes(result.Content, maxEmbedDescription),
+		Color:       color,
+		Fields: []*domain.MessageEmbedField{
+			{Name: l.T(msgAnswerField), Value: truncateRunes(answer, maxEmbedFieldValue)},
+		},
+	}
+
+	// Show the vote breakdown when the answer was judged by several samples
+	if result.Samples() > 1 {
+		embed.Footer = &domain.MessageEmbedFooter{Text: result.Breakdown(l)}
+	}
+
+	return embed
+}
+
+// solutionEmbed is the card which reveals the solution behind a spoiler
+func solutionEmbed(l *localizer, explanation string) *domain.MessageEmbed {
+	// Leave room for the spoiler markers
+	return &domain.MessageEmbed{
+		Title:       l.T(msgSolutionTitle),
+		Description: "||" + truncateRunes(explanation, maxEmbedDescription-4) + "||",
+		Color:       colorSolution,
+		Footer:      &domain.MessageEmbedFooter{Text: l.T(msgSolutionSpoiler)},
+	}
+}
+
+// solutionResponse reveals the solution as the card, or as the plain content when
+// the explanation does not fit in the card. The long content is split into several
+// messages or attached as a file by the session instead of being cut off.
+func solutionResponse(l *localizer, explanation string) *domain.InteractionResponseData {
+	if utf8.RuneCountInString(explanation) <= maxEmbedDescription-4 {
+		return &domain.InteractionResponseData{Embeds: []*domain.MessageEmbed{solutionEmbed(l, explanation)}}
+	}
+
+	return &domain.InteractionResponseData{
+		Content: "**" + l.T(msgSolutionTitle) + "**\n||" + explanation + "||\n" + l.T(msgSolutionSpoiler),
+	}
+}
+
+// truncateRunes shortens the text to the limit counted in characters
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
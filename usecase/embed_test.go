@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gong023/umi/domain"
+)
+
+func TestVerdictEmbed_Color(t *testing.T) {
+	tests := []struct {
+		verdict answerVerdict
+		color   int
+	}{
+		{verdictCorrect, colorCorrect},
+		{verdictIncorrect, colorIncorrect},
+		{verdictClose, colorClose},
+	}
+
+	for _, tt := range tests {
+		embed := verdictEmbed(localizerFor(nil), "母のスープと同じ味だった", &judgeResult{Verdict: tt.verdict, Content: "判定"})
+		if embed.Color != tt.color {
+			t.Errorf("Expected color %#x for %s, got %#x", tt.color, tt.verdict, embed.Color)
+		}
+		if embed.Title != "判定: "+string(tt.verdict) {
+			t.Errorf("Unexpected title: %s", embed.Title)
+		}
+		if embed.Footer != nil {
+			t.Errorf("Expected no footer for a single sample, got %s", embed.Footer.Text)
+		}
+	}
+
+	// The vote breakdown is shown in the footer when several samples were taken
+	embed := verdictEmbed(localizerFor(nil), "回答", &judgeResult{Verdict: verdictCorrect, Correct: 2, Incorrect: 1, Confidence: 2.0 / 3})
+	if embed.Footer == nil || !strings.Contains(embed.Footer.Text, "判定投票") {
+		t.Errorf("Expected the vote breakdown in the footer, got %+v", embed.Footer)
+	}
+}
+
+func TestQAnswerEmbed(t *testing.T) {
+	embed := qAnswerEmbed(localizerFor(nil), "男性は何を飲んでいましたか？", &parsedQAnswer{Answer: domain.QAnswerImportantYes})
+	if embed.Color != colorCorrect {
+		t.Errorf("Expected color %#x, got %#x", colorCorrect, embed.Color)
+	}
+	if len(embed.Fields) != 2 || embed.Fields[1].Value != "重要！はい" {
+		t.Errorf("Unexpected fields: %+v", embed.Fields)
+	}
+	if embed.Footer == nil {
+		t.Errorf("Expected a footer for an important answer")
+	}
+}
+
+func TestSolutionEmbed_Spoiler(t *testing.T) {
+	embed := solutionEmbed(localizerFor(nil), "男性は母のスープの味を思い出した。")
+	if !strings.HasPrefix(embed.Description, "||") || !strings.HasSuffix(embed.Description, "||") {
+		t.Errorf("Expected the solution behind a spoiler, got %s", embed.Description)
+	}
+
+	long := solutionEmbed(localizerFor(nil), strings.Repeat("あ", maxEmbedDescription))
+	if length := len([]rune(long.Description)); length > maxEmbedDescription {
+		t.Errorf("Expected the description within %d characters, got %d", maxEmbedDescription, length)
+	}
+}
+
+func TestSolutionResponse_Long(t *testing.T) {
+	l := localizerFor(nil)
+
+	// The short explanation is shown as the card
+	short := solutionResponse(l, "男性は母のスープの味を思い出した。")
+	if len(short.Embeds) != 1 || short.Content != "" {
+		t.Errorf("Expected the solution card, got %+v", short)
+	}
+
+	// The long explanation is sent as the content to be split, without being cut off
+	explanation := strings.Repeat("あ", maxEmbedDescription)
+	long := solutionResponse(l, explanation)
+	if len(long.Embeds) != 0 || !strings.Contains(long.Content, "||"+explanation+"||") {
+		t.Errorf("Expected the whole explanation behind a spoiler in the content, got %d characters", len([]rune(long.Content)))
+	}
+}
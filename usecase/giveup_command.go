@@ -1,9 +1,6 @@
 package usecase
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/gong023/umi/domain"
@@ -11,45 +8,77 @@ import (
 
 type GiveupCommandHandler struct {
 	openaiClient domain.OpenAIClient
+	fileSystem   domain.FileSystem
+	gameStore    domain.GameStore
 	logger       domain.Logger
+	authorizer   *Authorizer
+	voting       *Voting
 }
 
-func NewGiveupCommandHandler(openaiClient domain.OpenAIClient, logger domain.Logger) *GiveupCommandHandler {
+func NewGiveupCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, gameStore domain.GameStore, logger domain.Logger) *GiveupCommandHandler {
 	return &GiveupCommandHandler{
 		openaiClient: openaiClient,
+		fileSystem:   fileSystem,
+		gameStore:    gameStore,
 		logger:       logger,
 	}
 }
 
+// SetAuthorizer restricts who may use the command with the policy of the authorizer
+func (h *GiveupCommandHandler) SetAuthorizer(authorizer *Authorizer) {
+	h.authorizer = authorizer
+}
+
+// SetVoting makes the command start a vote of the players instead of ending the game alone
+func (h *GiveupCommandHandler) SetVoting(voting *Voting) {
+	h.voting = voting
+	voting.register("giveup", h)
+}
+
+func (h *GiveupCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "giveup",
+	})
+}
+
 func (h *GiveupCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling giveup command")
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "クイズの答えを取得しています...",
-		},
-	}
+	// End the game, or start a vote, as the policy and the voting decide
+	endGame(s, i, "giveup", h.authorizer, h.voting, h.finish)
+}
+
+// finish gives up the game, after the vote passed when the voting is on
+func (h *GiveupCommandHandler) finish(s domain.Session, i *domain.InteractionCreate) {
+	l := localizerFor(i)
 
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
 
 	// Check if a quiz exists
-	contextPath := filepath.Join("memo", "context.txt")
+	contextPath := h.fileSystem.JoinPath("memo", "context.txt")
 	quizExists := false
 	var contextContent []byte
 	var conversationHistory []string
 
 	// Check if the context file exists
-	if _, err := os.Stat(contextPath); err == nil {
+	exists, err := h.fileSystem.FileExists(contextPath)
+	if err != nil {
+		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+
+	if exists {
 		// Read the existing context file
-		contextContent, err = os.ReadFile(contextPath)
+		contextContent, err = h.fileSystem.ReadFile(contextPath)
 		if err != nil {
 			h.logger.Error("Failed to read context file: %v", err)
+			failResponse(s, i, h.logger)
 			return
 		}
 
@@ -64,11 +93,11 @@ func (h *GiveupCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	if !quizExists {
 		h.logger.Info("No quiz found")
 		// Send a response indicating that no quiz is available
-		followupMessage := "現在クイズが存在しません。`/create` コマンドで新しいクイズを作成してください。"
+		followupMessage := l.T(msgNoQuiz)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, followupMessage); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editResponse(s, i, followupMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		h.logger.Info("No quiz available, suggesting /create command: %s", followupMessage)
@@ -76,10 +105,11 @@ func (h *GiveupCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	}
 
 	// Read the prompt file
-	promptPath := filepath.Join("memo", "prompt", "onGiveup.txt")
-	promptContent, err := os.ReadFile(promptPath)
+	promptPath := h.fileSystem.JoinPath("memo", "prompt", "onGiveup.txt")
+	promptContent, err := h.fileSystem.ReadFile(promptPath)
 	if err != nil {
 		h.logger.Error("Failed to read prompt file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -91,6 +121,17 @@ func (h *GiveupCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 		},
 	}
 
+	// Give the stored solution to the model so that it explains the same truth
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+	if solution, ok := solutionMessage(game, true); ok {
+		messages = append(messages, solution)
+	}
+
 	// Process the conversation history and add it to the messages
 	if len(conversationHistory) > 0 {
 		// Add all conversation history
@@ -137,33 +178,42 @@ func (h *GiveupCommandHandler) Handle(s domain.Session, i *domain.InteractionCre
 	resp, err := h.openaiClient.CreateChatCompletion(req)
 	if err != nil {
 		h.logger.Error("Failed to create chat completion: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
 	// Extract the answer from the response
 	if len(resp.Choices) == 0 {
 		h.logger.Error("No choices in response")
+		failResponse(s, i, h.logger)
 		return
 	}
 
 	answer := resp.Choices[0].Message.Content
 	h.logger.Info("Received answer: %s", answer)
 
-	// Format the answer
-	formattedAnswer := fmt.Sprintf("**クイズの正解**\n\n%s", strings.TrimSpace(answer))
+	// Reveal the solution behind a spoiler
+	solution := solutionResponse(l, strings.TrimSpace(answer))
 
 	// Delete the context file
-	if err := os.Remove(contextPath); err != nil {
+	if err := h.fileSystem.RemoveFile(contextPath); err != nil {
 		h.logger.Error("Failed to delete context file: %v", err)
 		// Continue with the response even if we fail to delete the context file
 	} else {
 		h.logger.Info("Deleted context file after giveup")
 	}
 
+	if err := h.gameStore.Delete(); err != nil {
+		h.logger.Error("Failed to delete game record: %v", err)
+	}
+
 	// Send the response with the answer
-	if err := s.FollowupMessage(i, formattedAnswer); err != nil {
-		h.logger.Error("Failed to send follow-up message: %v", err)
+	if err := s.InteractionResponseEdit(i, solution); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
 	}
 
-	h.logger.Info("Answer provided: %s", formattedAnswer)
+	// Close the thread of the game with the solution
+	closeGameThread(s, i, game, solution, h.logger)
+
+	h.logger.Info("Answer provided: %s", answer)
 }
@@ -64,8 +64,11 @@ func TestGiveupCommandHandler_Handle(t *testing.T) {
 
 	mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).Return(mockResponse, nil)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the giveup command handler
-	handler := NewGiveupCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewGiveupCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -135,8 +138,11 @@ func TestGiveupCommandHandler_Handle_NoQuiz(t *testing.T) {
 	// First for the initial response, second for the follow-up response
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the giveup command handler
-	handler := NewGiveupCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewGiveupCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
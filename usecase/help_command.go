@@ -14,25 +14,19 @@ func NewHelpCommandHandler(logger domain.Logger) *HelpCommandHandler {
 	}
 }
 
+func (h *HelpCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name:         "help",
+		DMPermission: true,
+	})
+}
+
 func (h *HelpCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling help command")
 
 	// Create a response with the help message
-	helpMessage := `**ウミガメのスープクイズボットの使い方**
-
-以下のコマンドが利用可能です：
-
-- **/create** - 新しいクイズを作成します。クイズが既に存在する場合は、現在のクイズを表示します。
-- **/q [質問]** - クイズに関する質問をします。回答は「はい」「いいえ」「わからない/関係ない」のいずれかになります。
-- **/answer [回答]** - クイズの答えを提出します。正解の場合はクイズが終了し、不正解の場合はクイズが続行されます。
-- **/info** - 現在のクイズとこれまでの質問と回答の履歴を要約します。
-- **/clue** - 現在のクイズに関するヒントを提供します。
-- **/giveup** - クイズを諦め、正解を表示します。クイズは終了します。
-- **/quit** - 現在のクイズを終了します。
-- **/ping** - ボットが応答可能かどうかを確認します。
-- **/help** - このヘルプメッセージを表示します。
-
-クイズを始めるには、まず **/create** コマンドを使用してください。`
+	helpMessage := localizerFor(i).T(msgHelp)
 
 	response := &domain.InteractionResponse{
 		Type: int(domain.InteractionResponseChannelMessageWithSource),
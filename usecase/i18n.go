@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// messageKey identifies a player-facing message in the catalog
+type messageKey string
+
+const (
+	msgNoQuiz                 messageKey = "no_quiz"
+	msgFailure                messageKey = "failure"
+	msgPing                   messageKey = "ping"
+	msgHelp                   messageKey = "help"
+	msgCancelled              messageKey = "cancelled"
+	msgButtonVoteYes          messageKey = "button.vote_yes"
+	msgButtonVoteNo           messageKey = "button.vote_no"
+	msgButtonClue             messageKey = "button.clue"
+	msgButtonInfo             messageKey = "button.info"
+	msgButtonGiveup           messageKey = "button.giveup"
+	msgButtonAnswer           messageKey = "button.answer"
+	msgButtonCancel           messageKey = "button.cancel"
+	msgGiveupConfirm          messageKey = "giveup.confirm"
+	msgGiveupConfirmButton    messageKey = "giveup.confirm_button"
+	msgSolutionTitle          messageKey = "giveup.title"
+	msgSolutionSpoiler        messageKey = "giveup.spoiler"
+	msgAnswerModalTitle       messageKey = "answer.modal_title"
+	msgAnswerModalLabel       messageKey = "answer.modal_label"
+	msgAnswerModalPlaceholder messageKey = "answer.modal_placeholder"
+	msgAnswerEmpty            messageKey = "answer.empty"
+	msgAnswerField            messageKey = "answer.field"
+	msgVerdictTitle           messageKey = "verdict.title"
+	msgVerdictCorrect         messageKey = "verdict.correct"
+	msgVerdictIncorrect       messageKey = "verdict.incorrect"
+	msgVerdictClose           messageKey = "verdict.close"
+	msgJudgeCorrect           messageKey = "judge.correct"
+	msgJudgeIncorrect         messageKey = "judge.incorrect"
+	msgJudgeClose             messageKey = "judge.close"
+	msgJudgeCloseHint         messageKey = "judge.close_hint"
+	msgJudgeBreakdown         messageKey = "judge.breakdown"
+	msgJudgeProgress          messageKey = "judge.progress"
+	msgQEmpty                 messageKey = "q.empty"
+	msgQRefused               messageKey = "q.refused"
+	msgQUnanswerable          messageKey = "q.unanswerable"
+	msgQAnswerNote            messageKey = "q.answer_note"
+	msgQTitle                 messageKey = "q.title"
+	msgQField                 messageKey = "q.field"
+	msgQImportant             messageKey = "q.important"
+	msgClueTitle              messageKey = "clue.title"
+	msgClueLeaked             messageKey = "clue.leaked"
+	msgCluePrivateNote        messageKey = "clue.private_note"
+	msgInfoTitle              messageKey = "info.title"
+	msgInfoStats              messageKey = "info.stats"
+	msgInfoClues              messageKey = "info.clues"
+	msgPuzzleNewTitle         messageKey = "puzzle.new_title"
+	msgPuzzleCurrentTitle     messageKey = "puzzle.current_title"
+	msgPuzzleNewFooter        messageKey = "puzzle.new_footer"
+	msgPuzzleCurrentFooter    messageKey = "puzzle.current_footer"
+	msgThreadStarted          messageKey = "thread.started"
+	msgAuthDenied             messageKey = "auth.denied"
+	msgVoteStatus             messageKey = "vote.status"
+	msgVoteRecorded           messageKey = "vote.recorded"
+	msgVotePassed             messageKey = "vote.passed"
+	msgVoteExpired            messageKey = "vote.expired"
+	msgVoteRejected           messageKey = "vote.rejected"
+	msgVoteBusy               messageKey = "vote.busy"
+	msgVoteNone               messageKey = "vote.none"
+	msgRateLimitUser          messageKey = "rate_limit.user"
+	msgRateLimitChannel       messageKey = "rate_limit.channel"
+	msgRateLimitQuestions     messageKey = "rate_limit.questions"
+	msgBusy                   messageKey = "busy"
+	msgRestarting             messageKey = "restarting"
+	msgUnavailable            messageKey = "unavailable"
+	msgPanicApology           messageKey = "panic.apology"
+	msgQuitDone               messageKey = "quit.done"
+	msgQuitFailed             messageKey = "quit.failed"
+)
+
+// qAnswerKey returns the key of the label shown to the players for the answer
+func qAnswerKey(answer domain.QAnswer) messageKey {
+	return messageKey("qanswer." + string(answer))
+}
+
+// commandKey returns the key of the description of the command or its option
+func commandKey(name string, option ...string) messageKey {
+	if len(option) > 0 {
+		return messageKey("command." + name + "." + option[0])
+	}
+	return messageKey("command." + name)
+}
+
+// commandNameKey returns the key of the name of the command or its option as shown by Discord
+func commandNameKey(name string, option ...string) messageKey {
+	if len(option) > 0 {
+		return messageKey("command_name." + name + "." + option[0])
+	}
+	return messageKey("command_name." + name)
+}
+
+const defaultLanguage = "ja"
+
+// bundles are the messages of each language, keyed by the language part of the Discord locale
+var bundles = map[string]map[messageKey]string{
+	"ja": jaMessages,
+	"en": enMessages,
+}
+
+// discordLocales are the Discord locales the bundles are registered for as command localizations
+var discordLocales = map[string][]string{
+	"ja": {"ja"},
+	"en": {"en-US", "en-GB"},
+}
+
+// localizer chooses the messages in the language of the interaction
+type localizer struct {
+	language string
+}
+
+// localizerFor prefers the locale of the user, then the locale of the guild,
+// and falls back to Japanese, the language the game is played in.
+func localizerFor(i *domain.InteractionCreate) *localizer {
+	if i != nil {
+		for _, locale := range []string{i.Locale, i.GuildLocale} {
+			if language, ok := languageOf(locale); ok {
+				return &localizer{language: language}
+			}
+		}
+	}
+	return &localizer{language: defaultLanguage}
+}
+
+func languageOf(locale string) (string, bool) {
+	language, _, _ := strings.Cut(locale, "-")
+	if _, ok := bundles[language]; !ok {
+		return "", false
+	}
+	return language, true
+}
+
+// T returns the message of the key formatted with the args
+func (l *localizer) T(key messageKey, args ...interface{}) string {
+	message, ok := bundles[l.language][key]
+	if !ok {
+		message, ok = bundles[defaultLanguage][key]
+	}
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Command returns the name of the command in the language, as Discord shows it to the user
+func (l *localizer) Command(name string) string {
+	if _, ok := bundles[defaultLanguage][commandNameKey(name)]; !ok {
+		return name
+	}
+	return l.T(commandNameKey(name))
+}
+
+// commandLocalizations returns the translations of the message for the Discord locales
+// other than the default one, which is English for the command definitions.
+func commandLocalizations(key messageKey) map[string]string {
+	localizations := make(map[string]string)
+	for language, locales := range discordLocales {
+		if language == "en" {
+			continue
+		}
+		message, ok := bundles[language][key]
+		if !ok {
+			continue
+		}
+		for _, locale := range locales {
+			localizations[locale] = message
+		}
+	}
+	return localizations
+}
+
+// describeCommand fills the localized names and the descriptions of the command and its options
+// from the catalog. The names themselves stay in English, which the interactions are routed by.
+func describeCommand(command *domain.ApplicationCommand) *domain.ApplicationCommand {
+	english := &localizer{language: "en"}
+
+	command.NameLocalizations = commandLocalizations(commandNameKey(command.Name))
+	command.Description = english.T(commandKey(command.Name))
+	command.DescriptionLocalizations = commandLocalizations(commandKey(command.Name))
+
+	for _, option := range command.Options {
+		key := commandKey(command.Name, option.Name)
+		option.NameLocalizations = commandLocalizations(commandNameKey(command.Name, option.Name))
+		option.Description = english.T(key)
+		option.DescriptionLocalizations = commandLocalizations(key)
+	}
+
+	return command
+}
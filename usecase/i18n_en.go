@@ -0,0 +1,136 @@
+package usecase
+
+var enMessages = map[messageKey]string{
+	msgNoQuiz:    "There is no quiz right now. Create a new one with the `/create` command.",
+	msgFailure:   "Something went wrong. Please try again later.",
+	msgPing:      "Yes!",
+	msgCancelled: "Cancelled.",
+	msgHelp: `**How to use the Umigame no Soup quiz bot**
+
+The following commands are available:
+
+- **/create** - Creates a new quiz. If a quiz already exists, shows the current one.
+- **/q [question]** - Asks a question about the quiz. The answer is one of "Yes", "No", "Irrelevant", "Partially yes" and "Important! Yes".
+- **/answer [answer]** - Submits an answer to the quiz. Without the answer, a field to write a long answer opens. It shows how many key points of the solution the answer covers, and the quiz ends once enough of them are covered.
+- **/info** - Summarizes the current quiz and the questions and answers so far.
+- **/clue** - Gives a clue about the current quiz.
+- **/giveup** - Gives up the quiz and reveals the solution. The quiz ends.
+- **/quit** - Quits the current quiz.
+- **/ping** - Checks if the bot is responding.
+- **/help** - Shows this help message.
+
+The buttons on the puzzle also give a clue, a summary, give up or open the answer field.
+
+To start, use the **/create** command.`,
+
+	msgButtonClue:    "Clue",
+	msgButtonInfo:    "Summary",
+	msgButtonGiveup:  "Give up",
+	msgButtonAnswer:  "Answer",
+	msgButtonCancel:  "Cancel",
+	msgButtonVoteYes: "Yes",
+	msgButtonVoteNo:  "No",
+
+	msgGiveupConfirm:       "Do you really want to give up? The solution is revealed and the quiz ends.",
+	msgGiveupConfirmButton: "Give up",
+	msgSolutionTitle:       "Solution",
+	msgSolutionSpoiler:     "Hidden to avoid spoilers. Click to reveal.",
+
+	msgAnswerModalTitle:       "Answer",
+	msgAnswerModalLabel:       "Answer",
+	msgAnswerModalPlaceholder: "The man had eaten turtle soup before, and remembered what his wife had made",
+	msgAnswerEmpty:            "Please enter your answer. Example: `/answer The man realized what he had actually eaten before`",
+	msgAnswerField:            "Answer",
+
+	msgVerdictTitle:     "Verdict: %s",
+	msgVerdictCorrect:   "Correct",
+	msgVerdictIncorrect: "Incorrect",
+	msgVerdictClose:     "Close",
+	msgJudgeCorrect:     "Correct!",
+	msgJudgeIncorrect:   "Incorrect.",
+	msgJudgeClose:       "Close! The judges were split.",
+	msgJudgeCloseHint:   "Close! The judges were split. You may be near the core of it, think a little more.",
+	msgJudgeBreakdown:   "Votes: correct %d / incorrect %d (agreement %.0f%%) → %s",
+	msgJudgeProgress:    "%s %d/%d key points covered",
+
+	msgQEmpty:        "Please enter your question. Example: `/q What was the man drinking?`",
+	msgQRefused:      "That question can't be answered. Please ask a question which can be answered with yes or no.",
+	msgQUnanswerable: "The question could not be answered. Please rephrase it and try again.",
+	msgQAnswerNote:   "%s (%s)",
+	msgQTitle:        "Question",
+	msgQField:        "Question",
+	msgQImportant:    "This question gets to the heart of it!",
+
+	"qanswer.yes":           "Yes",
+	"qanswer.no":            "No",
+	"qanswer.irrelevant":    "Irrelevant",
+	"qanswer.partially":     "Partially yes",
+	"qanswer.important-yes": "Important! Yes",
+
+	msgClueTitle:       "**Clue**: %s",
+	msgClueLeaked:      "The clue was too close to the solution to show. Please try `/clue` again.",
+	msgCluePrivateNote: "🔒 %s used a private clue.",
+
+	msgInfoTitle: "**Quiz summary**\n\n%s",
+	msgInfoStats: "**Questions**: %d in total (%s)",
+	msgInfoClues: "**Clues used**: %d public / %d private",
+
+	msgPuzzleNewTitle:      "New Umigame no Soup quiz",
+	msgPuzzleCurrentTitle:  "Current Umigame no Soup quiz",
+	msgPuzzleNewFooter:     "Ask with /q, answer with /answer and get a clue with /clue.",
+	msgPuzzleCurrentFooter: "Use the /quit command to quit the current quiz.",
+
+	msgAuthDenied: "You are not allowed to use `/%s`. Please ask the creator of the quiz or a game master.",
+
+	msgVoteStatus:   "🗳️ Voting for `/%s`: %d yes of %d needed (%d no)\nCloses <t:%d:R>",
+	msgVoteRecorded: "You voted yes (%d/%d).",
+	msgVotePassed:   "✅ The vote for `/%s` passed (%d/%d).",
+	msgVoteExpired:  "⌛ The vote for `/%s` expired.",
+	msgVoteRejected: "❌ The vote for `/%s` was rejected (%d against).",
+	msgVoteBusy:     "A vote for `/%s` is in progress. Please wait until it ends.",
+	msgVoteNone:     "There is no vote in progress.",
+
+	msgRateLimitUser:      "⏳ Please wait a moment. You can use `/%s` again <t:%d:R>.",
+	msgRateLimitChannel:   "⏳ `/%s` was just used in this channel. You can use it again <t:%d:R>.",
+	msgRateLimitQuestions: "⏳ Too many questions for this quiz. You can use `/%s` again <t:%d:R>.",
+
+	msgBusy:         "The bot is busy right now. Please wait a moment and try again.",
+	msgRestarting:   "The bot is restarting. Please wait a moment and try again.",
+	msgUnavailable:  "This command is not available right now. Please wait until the commands are updated.",
+	msgPanicApology: "Sorry, something unexpected went wrong. Please try again.",
+
+	msgThreadStarted: "Started the quiz in a thread: <#%s>",
+
+	msgQuitDone:   "The quiz has been quit. Use the `/create` command to start a new one.",
+	msgQuitFailed: "Failed to quit the quiz.",
+
+	"command.ping":           "Check if the bot is running",
+	"command.help":           "Show how to use the commands",
+	"command.quiz":           "Generate a quiz without starting a game",
+	"command.create":         "Create a new quiz or show the current one",
+	"command.create.thread":  "Play the quiz in a new thread",
+	"command.q":              "Ask a yes/no question about the quiz",
+	"command.q.message":      "The question to ask",
+	"command.answer":         "Submit an answer to the quiz",
+	"command.answer.message": "The answer to submit (opens a form when omitted)",
+	"command.info":           "Summarize the quiz and what has been clarified",
+	"command.clue":           "Get a clue about the quiz",
+	"command.clue.private":   "Show the clue only to you",
+	"command.giveup":         "Give up and reveal the solution",
+	"command.quit":           "Quit the current quiz without revealing the solution",
+
+	"command_name.ping":           "ping",
+	"command_name.help":           "help",
+	"command_name.quiz":           "quiz",
+	"command_name.create":         "create",
+	"command_name.create.thread":  "thread",
+	"command_name.q":              "q",
+	"command_name.q.message":      "message",
+	"command_name.answer":         "answer",
+	"command_name.answer.message": "message",
+	"command_name.info":           "info",
+	"command_name.clue":           "clue",
+	"command_name.clue.private":   "private",
+	"command_name.giveup":         "giveup",
+	"command_name.quit":           "quit",
+}
@@ -0,0 +1,136 @@
+package usecase
+
+var jaMessages = map[messageKey]string{
+	msgNoQuiz:    "現在クイズが存在しません。`/出題` コマンドで新しいクイズを作成してください。",
+	msgFailure:   "エラーが発生しました。しばらくしてからもう一度お試しください。",
+	msgPing:      "はい!",
+	msgCancelled: "キャンセルしました。",
+	msgHelp: `**ウミガメのスープクイズボットの使い方**
+
+以下のコマンドが利用可能です：
+
+- **/出題** - 新しいクイズを作成します。クイズが既に存在する場合は、現在のクイズを表示します。
+- **/質問 [質問]** - クイズに関する質問をします。回答は「はい」「いいえ」「関係ありません」「部分的にはい」「重要！はい」のいずれかになります。
+- **/回答 [回答]** - クイズの答えを提出します。回答を省略すると、長い回答を書ける入力欄が開きます。正解の要素のうちいくつ合っているかが表示され、十分な要素が合っていればクイズが終了し、そうでなければクイズが続行されます。
+- **/まとめ** - 現在のクイズとこれまでの質問と回答の履歴を要約します。
+- **/ヒント** - 現在のクイズに関するヒントを提供します。
+- **/ギブアップ** - クイズを諦め、正解を表示します。クイズは終了します。
+- **/終了** - 現在のクイズを終了します。
+- **/ping** - ボットが応答可能かどうかを確認します。
+- **/ヘルプ** - このヘルプメッセージを表示します。
+
+クイズの問題に付いているボタンからも、ヒント・状況まとめ・ギブアップ・回答ができます。
+
+クイズを始めるには、まず **/出題** コマンドを使用してください。`,
+
+	msgButtonClue:    "ヒント",
+	msgButtonInfo:    "状況まとめ",
+	msgButtonGiveup:  "ギブアップ",
+	msgButtonAnswer:  "回答する",
+	msgButtonCancel:  "キャンセル",
+	msgButtonVoteYes: "賛成",
+	msgButtonVoteNo:  "反対",
+
+	msgGiveupConfirm:       "本当にギブアップしますか？正解が表示され、クイズは終了します。",
+	msgGiveupConfirmButton: "ギブアップする",
+	msgSolutionTitle:       "クイズの正解",
+	msgSolutionSpoiler:     "ネタバレ防止のため伏せています。クリックすると表示されます。",
+
+	msgAnswerModalTitle:       "回答する",
+	msgAnswerModalLabel:       "回答",
+	msgAnswerModalPlaceholder: "男性は亀のスープを飲んだことがあり、妻が亀のスープを作ったことを思い出して自殺した",
+	msgAnswerEmpty:            "回答を入力してください。例: `/回答 男性は亀のスープを飲んだことがあり、妻が亀のスープを作ったことを思い出して自殺した`",
+	msgAnswerField:            "回答",
+
+	msgVerdictTitle:     "判定: %s",
+	msgVerdictCorrect:   "正解",
+	msgVerdictIncorrect: "不正解",
+	msgVerdictClose:     "惜しい",
+	msgJudgeCorrect:     "正解です！",
+	msgJudgeIncorrect:   "不正解です。",
+	msgJudgeClose:       "惜しい！判定が分かれました。",
+	msgJudgeCloseHint:   "惜しい！判定が分かれました。核心に近づいているかもしれません。もう少し考えてみてください。",
+	msgJudgeBreakdown:   "判定投票: 正解 %d / 不正解 %d (一致率 %.0f%%) → %s",
+	msgJudgeProgress:    "%s %d/%d の要素が合っています",
+
+	msgQEmpty:        "質問を入力してください。例: `/質問 男性は何を飲んでいましたか？`",
+	msgQRefused:      "その質問にはお答えできません。「はい」「いいえ」で答えられる質問をしてください。",
+	msgQUnanswerable: "うまく回答できませんでした。質問を言い換えてもう一度試してください。",
+	msgQAnswerNote:   "%s（%s）",
+	msgQTitle:        "質問",
+	msgQField:        "質問",
+	msgQImportant:    "核心に迫る質問です！",
+
+	"qanswer.yes":           "はい",
+	"qanswer.no":            "いいえ",
+	"qanswer.irrelevant":    "関係ありません",
+	"qanswer.partially":     "部分的にはい",
+	"qanswer.important-yes": "重要！はい",
+
+	msgClueTitle:       "**ヒント**: %s",
+	msgClueLeaked:      "ヒントが答えに近すぎたため表示できませんでした。もう一度 `/ヒント` を試してください。",
+	msgCluePrivateNote: "🔒 %s さんが非公開のヒントを見ました。",
+
+	msgInfoTitle: "**クイズ情報**\n\n%s",
+	msgInfoStats: "**質問の集計**: 全%d問（%s）",
+	msgInfoClues: "**ヒントの使用**: 公開%d件 / 非公開%d件",
+
+	msgPuzzleNewTitle:      "新しいウミガメのスープクイズ",
+	msgPuzzleCurrentTitle:  "現在のウミガメのスープクイズ",
+	msgPuzzleNewFooter:     "/質問 で質問、/回答 で回答、/ヒント でヒントを得られます。",
+	msgPuzzleCurrentFooter: "現在のクイズを終了するには /終了 コマンドを使用してください。",
+
+	msgAuthDenied: "`/%s` を使う権限がありません。クイズの作成者かゲームマスターに頼んでください。",
+
+	msgVoteStatus:   "🗳️ `/%s` の投票中です: 賛成 %d / 必要 %d（反対 %d）\n締め切り: <t:%d:R>",
+	msgVoteRecorded: "賛成に投票しました（%d/%d）。",
+	msgVotePassed:   "✅ `/%s` の投票が可決されました（%d/%d）。",
+	msgVoteExpired:  "⌛ `/%s` の投票は期限切れになりました。",
+	msgVoteRejected: "❌ `/%s` の投票は否決されました（反対 %d）。",
+	msgVoteBusy:     "`/%s` の投票が進行中です。終わるまでお待ちください。",
+	msgVoteNone:     "進行中の投票はありません。",
+
+	msgRateLimitUser:      "⏳ 少し待ってください。`/%s` は <t:%d:R> にまた使えます。",
+	msgRateLimitChannel:   "⏳ このチャンネルでは `/%s` が使われたばかりです。<t:%d:R> にまた使えます。",
+	msgRateLimitQuestions: "⏳ このクイズへの質問が続いています。`/%s` は <t:%d:R> にまた使えます。",
+
+	msgBusy:         "ただいま混み合っています。少し待ってからもう一度お試しください。",
+	msgRestarting:   "再起動中です。少し待ってからもう一度お試しください。",
+	msgUnavailable:  "このコマンドは現在使えません。コマンドの一覧が更新されるまでお待ちください。",
+	msgPanicApology: "申し訳ありません、予期しないエラーが発生しました。もう一度お試しください。",
+
+	msgThreadStarted: "スレッドでクイズを始めました: <#%s>",
+
+	msgQuitDone:   "クイズを終了しました。新しいクイズを始めるには `/出題` コマンドを使用してください。",
+	msgQuitFailed: "クイズの終了に失敗しました。",
+
+	"command.ping":           "ボットが応答可能かどうかを確認します",
+	"command.help":           "コマンドの使い方を表示します",
+	"command.quiz":           "ゲームを始めずにクイズを生成します",
+	"command.create":         "新しいクイズを作成するか、現在のクイズを表示します",
+	"command.create.thread":  "スレッドを作ってその中でクイズを遊びます",
+	"command.q":              "クイズについて「はい」「いいえ」で答えられる質問をします",
+	"command.q.message":      "質問",
+	"command.answer":         "クイズの答えを提出します",
+	"command.answer.message": "回答（省略すると入力欄が開きます）",
+	"command.info":           "クイズとこれまでに分かったことを要約します",
+	"command.clue":           "クイズのヒントを得ます",
+	"command.clue.private":   "自分だけにヒントを表示します",
+	"command.giveup":         "クイズを諦めて正解を表示します",
+	"command.quit":           "正解を表示せずに現在のクイズを終了します",
+
+	"command_name.ping":           "ping",
+	"command_name.help":           "ヘルプ",
+	"command_name.quiz":           "クイズ",
+	"command_name.create":         "出題",
+	"command_name.create.thread":  "スレッド",
+	"command_name.q":              "質問",
+	"command_name.q.message":      "質問",
+	"command_name.answer":         "回答",
+	"command_name.answer.message": "回答",
+	"command_name.info":           "まとめ",
+	"command_name.clue":           "ヒント",
+	"command_name.clue.private":   "非公開",
+	"command_name.giveup":         "ギブアップ",
+	"command_name.quit":           "終了",
+}
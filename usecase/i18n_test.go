@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// commandName is the names Discord accepts for the commands and the options
+var commandName = regexp.MustCompile(`^[-_\p{L}\p{N}]{1,32}$`)
+
+// commandDefinitions returns the definitions of every command
+func commandDefinitions(t *testing.T) []*domain.ApplicationCommand {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	logger := mock.NewMockLogger(ctrl)
+
+	commands := []domain.Command{
+		NewPingCommandHandler(logger),
+		NewHelpCommandHandler(logger),
+		NewQuizCommandHandler(nil, logger),
+		NewCreateCommandHandler(nil, nil, nil, logger),
+		NewQCommandHandler(nil, nil, nil, nil, logger),
+		NewAnswerCommandHandler(nil, nil, nil, nil, logger),
+		NewInfoCommandHandler(nil, nil, nil, logger),
+		NewClueCommandHandler(nil, nil, nil, nil, logger),
+		NewGiveupCommandHandler(nil, nil, nil, logger),
+		NewQuitCommandHandler(nil, nil, logger),
+	}
+	definitions := make([]*domain.ApplicationCommand, 0, len(commands))
+	for _, command := range commands {
+		definitions = append(definitions, command.Definition())
+	}
+
+	return definitions
+}
+
+// catalogKeys returns every key used by the bot, including the ones built from
+// the answers and the command definitions
+func catalogKeys(t *testing.T) map[messageKey]bool {
+	keys := make(map[messageKey]bool)
+	for _, bundle := range bundles {
+		for key := range bundle {
+			keys[key] = true
+		}
+	}
+
+	for _, answer := range domain.QAnswers {
+		keys[qAnswerKey(answer)] = true
+	}
+
+	for _, definition := range commandDefinitions(t) {
+		keys[commandKey(definition.Name)] = true
+		keys[commandNameKey(definition.Name)] = true
+		for _, option := range definition.Options {
+			keys[commandKey(definition.Name, option.Name)] = true
+			keys[commandNameKey(definition.Name, option.Name)] = true
+		}
+	}
+
+	return keys
+}
+
+func TestBundles_CommandNames(t *testing.T) {
+	for _, definition := range commandDefinitions(t) {
+		names := map[messageKey]string{commandNameKey(definition.Name): definition.Name}
+		for _, option := range definition.Options {
+			names[commandNameKey(definition.Name, option.Name)] = option.Name
+		}
+
+		for key, name := range names {
+			// The English names are the names the interactions are routed by
+			if enMessages[key] != name {
+				t.Errorf("Expected the English name of %s to be %s, got %s", key, name, enMessages[key])
+			}
+
+			for language, bundle := range bundles {
+				localized := bundle[key]
+				if !commandName.MatchString(localized) || strings.ToLower(localized) != localized {
+					t.Errorf("Bundle %s has a name Discord does not accept for %s: %s", language, key, localized)
+				}
+			}
+		}
+	}
+}
+
+func TestBundles_HaveEveryKey(t *testing.T) {
+	keys := catalogKeys(t)
+
+	for language, bundle := range bundles {
+		for key := range keys {
+			message, ok := bundle[key]
+			if !ok {
+				t.Errorf("Bundle %s has no message for %s", language, key)
+				continue
+			}
+			if message == "" {
+				t.Errorf("Bundle %s has an empty message for %s", language, key)
+			}
+		}
+	}
+}
+
+func TestBundles_SameFormatVerbs(t *testing.T) {
+	for key, message := range bundles[defaultLanguage] {
+		expected := formatVerb.FindAllString(message, -1)
+
+		for language, bundle := range bundles {
+			actual := formatVerb.FindAllString(bundle[key], -1)
+			if len(actual) != len(expected) {
+				t.Errorf("Bundle %s has %d format verbs for %s, expected %d", language, len(actual), key, len(expected))
+				continue
+			}
+			for idx := range expected {
+				if actual[idx] != expected[idx] {
+					t.Errorf("Bundle %s has %s for %s, expected %s", language, actual[idx], key, expected[idx])
+				}
+			}
+		}
+	}
+}
+
+func TestLocalizerFor(t *testing.T) {
+	tests := []struct {
+		locale      string
+		guildLocale string
+		language    string
+	}{
+		{"ja", "en-US", "ja"},
+		{"en-US", "ja", "en"},
+		{"en-GB", "", "en"},
+		{"fr", "en-US", "en"},
+		{"fr", "de", "ja"},
+		{"", "", "ja"},
+	}
+
+	for _, tt := range tests {
+		l := localizerFor(&domain.InteractionCreate{Locale: tt.locale, GuildLocale: tt.guildLocale})
+		if l.language != tt.language {
+			t.Errorf("Expected %s for the locale %q and the guild locale %q, got %s", tt.language, tt.locale, tt.guildLocale, l.language)
+		}
+	}
+}
+
+func TestDescribeCommand(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	definition := NewQCommandHandler(nil, nil, nil, nil, mock.NewMockLogger(ctrl)).Definition()
+
+	// The default description is English, and the Japanese one is registered as a localization
+	if definition.Description != enMessages[commandKey("q")] {
+		t.Errorf("Expected the English description, got %s", definition.Description)
+	}
+	if definition.DescriptionLocalizations["ja"] != jaMessages[commandKey("q")] {
+		t.Errorf("Expected the Japanese localization, got %+v", definition.DescriptionLocalizations)
+	}
+	if definition.Options[0].DescriptionLocalizations["ja"] != jaMessages[commandKey("q", "message")] {
+		t.Errorf("Expected the Japanese localization of the option, got %+v", definition.Options[0].DescriptionLocalizations)
+	}
+
+	// The names are localized as well, while the names themselves stay in English
+	if definition.Name != "q" || definition.NameLocalizations["ja"] != jaMessages[commandNameKey("q")] {
+		t.Errorf("Expected the Japanese name, got %s %+v", definition.Name, definition.NameLocalizations)
+	}
+	if definition.Options[0].NameLocalizations["ja"] != jaMessages[commandNameKey("q", "message")] {
+		t.Errorf("Expected the Japanese name of the option, got %+v", definition.Options[0].NameLocalizations)
+	}
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// TestImports keeps the usecase package independent of the Discord API, which is
+// converted to the domain model in infra. The mocks in infra/mock are allowed.
+func TestImports(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+
+	for _, file := range files {
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+
+		for _, spec := range parsed.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+
+			if path == "github.com/bwmarrin/discordgo" || path == "github.com/gong023/umi/infra" {
+				t.Errorf("%s imports %s", file, path)
+			}
+		}
+	}
+}
@@ -2,8 +2,6 @@ package usecase
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/gong023/umi/domain"
@@ -11,45 +9,57 @@ import (
 
 type InfoCommandHandler struct {
 	openaiClient domain.OpenAIClient
+	fileSystem   domain.FileSystem
+	gameStore    domain.GameStore
 	logger       domain.Logger
 }
 
-func NewInfoCommandHandler(openaiClient domain.OpenAIClient, logger domain.Logger) *InfoCommandHandler {
+func NewInfoCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, gameStore domain.GameStore, logger domain.Logger) *InfoCommandHandler {
 	return &InfoCommandHandler{
 		openaiClient: openaiClient,
+		fileSystem:   fileSystem,
+		gameStore:    gameStore,
 		logger:       logger,
 	}
 }
 
+func (h *InfoCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "info",
+	})
+}
+
 func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling info command")
+	l := localizerFor(i)
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "情報を取得しています...",
-		},
-	}
-
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
 
 	// Check if a quiz exists
-	contextPath := filepath.Join("memo", "context.txt")
+	contextPath := h.fileSystem.JoinPath("memo", "context.txt")
 	quizExists := false
 	var contextContent []byte
 	var conversationHistory []string
 
 	// Check if the context file exists
-	if _, err := os.Stat(contextPath); err == nil {
+	exists, err := h.fileSystem.FileExists(contextPath)
+	if err != nil {
+		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+
+	if exists {
 		// Read the existing context file
-		contextContent, err = os.ReadFile(contextPath)
+		contextContent, err = h.fileSystem.ReadFile(contextPath)
 		if err != nil {
 			h.logger.Error("Failed to read context file: %v", err)
+			failResponse(s, i, h.logger)
 			return
 		}
 
@@ -64,11 +74,11 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	if !quizExists {
 		h.logger.Info("No quiz found")
 		// Send a response indicating that no quiz is available
-		followupMessage := "現在クイズが存在しません。`/create` コマンドで新しいクイズを作成してください。"
+		followupMessage := l.T(msgNoQuiz)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, followupMessage); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editResponse(s, i, followupMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		h.logger.Info("No quiz available, suggesting /create command: %s", followupMessage)
@@ -76,10 +86,11 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	}
 
 	// Read the prompt file
-	promptPath := filepath.Join("memo", "prompt", "onInfo.txt")
-	promptContent, err := os.ReadFile(promptPath)
+	promptPath := h.fileSystem.JoinPath("memo", "prompt", "onInfo.txt")
+	promptContent, err := h.fileSystem.ReadFile(promptPath)
 	if err != nil {
 		h.logger.Error("Failed to read prompt file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -91,6 +102,17 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 		},
 	}
 
+	// Give the stored solution to the model so that it is consistent with the other commands
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+	if solution, ok := solutionMessage(game, false); ok {
+		messages = append(messages, solution)
+	}
+
 	// Add all conversation history
 	for i, message := range conversationHistory {
 		if message == "" {
@@ -111,6 +133,14 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 		})
 	}
 
+	// Add the structured questions and answers so that the summary can rely on them
+	if len(game.Questions) > 0 {
+		messages = append(messages, domain.ChatMessage{
+			Role:    "system",
+			Content: "これまでの質問と回答の一覧:\n" + formatQuestionList(game.Questions),
+		})
+	}
+
 	req := &domain.ChatCompletionRequest{
 		Model:       "chatgpt-4o-latest",
 		Messages:    messages,
@@ -122,12 +152,14 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	resp, err := h.openaiClient.CreateChatCompletion(req)
 	if err != nil {
 		h.logger.Error("Failed to create chat completion: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
 	// Extract the info from the response
 	if len(resp.Choices) == 0 {
 		h.logger.Error("No choices in response")
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -135,7 +167,13 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	h.logger.Info("Received info: %s", info)
 
 	// Format the info
-	formattedInfo := fmt.Sprintf("**クイズ情報**\n\n%s", strings.TrimSpace(info))
+	formattedInfo := l.T(msgInfoTitle, strings.TrimSpace(info))
+	if len(game.Questions) > 0 {
+		formattedInfo += "\n\n" + formatQuestionStats(l, game.Questions)
+	}
+	if len(game.Clues) > 0 {
+		formattedInfo += "\n" + formatClueStats(l, game.Clues)
+	}
 
 	// Append the info to the context file
 	// First, read the existing content
@@ -152,7 +190,7 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	updatedContent += info
 
 	// Write the updated content back to the context file
-	if err := os.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
+	if err := h.fileSystem.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
 		h.logger.Error("Failed to update context file: %v", err)
 		// Continue with the response even if we fail to update the context file
 	} else {
@@ -160,9 +198,49 @@ func (h *InfoCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	}
 
 	// Send the response with the info
-	if err := s.FollowupMessage(i, formattedInfo); err != nil {
-		h.logger.Error("Failed to send follow-up message: %v", err)
+	if err := editResponse(s, i, formattedInfo); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
 	}
 
 	h.logger.Info("Info created: %s", formattedInfo)
 }
+
+func formatQuestionList(questions []*domain.QuestionRecord) string {
+	lines := make([]string, 0, len(questions))
+	for _, q := range questions {
+		line := fmt.Sprintf("- %s → %s", q.Question, q.Answer.Label())
+		if q.Note != "" {
+			line += fmt.Sprintf("（%s）", q.Note)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatQuestionStats(l *localizer, questions []*domain.QuestionRecord) string {
+	counts := make(map[domain.QAnswer]int)
+	for _, q := range questions {
+		counts[q.Answer]++
+	}
+
+	var parts []string
+	for _, answer := range domain.QAnswers {
+		if counts[answer] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", l.T(qAnswerKey(answer)), counts[answer]))
+		}
+	}
+
+	return l.T(msgInfoStats, len(questions), strings.Join(parts, " / "))
+}
+
+// formatClueStats counts the public and the private clues given so far
+func formatClueStats(l *localizer, clues []*domain.ClueRecord) string {
+	private := 0
+	for _, clue := range clues {
+		if clue.Private {
+			private++
+		}
+	}
+
+	return l.T(msgInfoClues, len(clues)-private, private)
+}
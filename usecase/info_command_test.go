@@ -65,8 +65,11 @@ func TestInfoCommandHandler_Handle(t *testing.T) {
 
 	mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).Return(mockResponse, nil)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the info command handler
-	handler := NewInfoCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewInfoCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -136,8 +139,11 @@ func TestInfoCommandHandler_Handle_NoQuiz(t *testing.T) {
 	// First for the initial response, second for the follow-up response
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the info command handler
-	handler := NewInfoCommandHandler(mockOpenAIClient, mockLogger)
+	handler := NewInfoCommandHandler(mockOpenAIClient, mock.NewMockFileSystem(ctrl), mockGameStore, mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// defaultAnswerMarker is the prefix of the messages answering the quiz in the message mode
+const defaultAnswerMarker = "回答:"
+
+// MessageModeHandler lets the players play without typing the slash commands.
+// In the channel or the thread of the game, the messages ending with a question
+// mark are asked as /q, and the messages starting with the answer marker are
+// judged as /answer. The other messages are ignored as a chat.
+type MessageModeHandler struct {
+	// handler runs the commands the messages are played as, such as BotService.Handler
+	// with the middlewares of the commands
+	handler      domain.CommandHandler
+	gameStore    domain.GameStore
+	logger       domain.Logger
+	answerMarker string
+}
+
+func NewMessageModeHandler(handler domain.CommandHandler, gameStore domain.GameStore, logger domain.Logger) *MessageModeHandler {
+	return &MessageModeHandler{
+		handler:      handler,
+		gameStore:    gameStore,
+		logger:       logger,
+		answerMarker: defaultAnswerMarker,
+	}
+}
+
+// SetAnswerMarker sets the prefix of the messages answering the quiz
+func (h *MessageModeHandler) SetAnswerMarker(marker string) {
+	h.answerMarker = marker
+}
+
+func (h *MessageModeHandler) HandleMessage(s domain.Session, m *domain.MessageCreate) {
+	// Ignore the messages of the bots, including the replies of this bot
+	if m.Bot {
+		return
+	}
+
+	// Ignore the ordinary chat before touching the game record
+	name, text := h.classify(m.Content)
+	if name == "" {
+		return
+	}
+
+	// Only the messages in the channel or the thread of the game are played
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+		return
+	}
+	if m.ChannelID == "" || (m.ChannelID != game.ChannelID && m.ChannelID != game.ThreadID) {
+		return
+	}
+
+	h.logger.Info("Handling message as %s command: %s", name, text)
+
+	// Reply in the thread of the game when it is played in a thread
+	channelID := m.ChannelID
+	if game.ThreadID != "" {
+		channelID = game.ThreadID
+	}
+
+	// Handle the message as the slash command with the message option
+	interaction := &domain.InteractionCreate{
+		ID:        m.ID,
+		Type:      int(domain.InteractionApplicationCommand),
+		ChannelID: channelID,
+		User:      &domain.User{ID: m.AuthorID},
+		Data: &domain.ApplicationCommandInteractionData{
+			Name: name,
+			Options: []*domain.ApplicationCommandInteractionDataOption{
+				{Name: "message", Value: text},
+			},
+		},
+	}
+	session := &messageSession{Session: s, channelID: channelID, message: m}
+
+	h.handler.Handle(session, interaction)
+}
+
+// classify returns the command the message is played as and its text,
+// or an empty name when the message is a chat
+func (h *MessageModeHandler) classify(content string) (string, string) {
+	content = strings.TrimSpace(content)
+
+	// The marker is accepted with a full-width colon as well, since it is typed with the IME
+	markers := []string{h.answerMarker, strings.ReplaceAll(h.answerMarker, ":", "：")}
+	for _, marker := range markers {
+		if marker == "" || !strings.HasPrefix(content, marker) {
+			continue
+		}
+		if answer := strings.TrimSpace(strings.TrimPrefix(content, marker)); answer != "" {
+			return "answer", answer
+		}
+		return "", ""
+	}
+
+	if strings.HasSuffix(content, "?") || strings.HasSuffix(content, "？") {
+		return "q", content
+	}
+
+	return "", ""
+}
+
+// messageSession turns the responses to the interaction into the replies to the message
+type messageSession struct {
+	domain.Session
+	channelID string
+	message   *domain.MessageCreate
+}
+
+func (s *messageSession) reply(data *domain.InteractionResponseData) error {
+	// A message can be replied to only in its own channel, so the thread gets a plain message
+	if s.channelID != s.message.ChannelID {
+		return s.Session.SendMessage(s.channelID, data)
+	}
+	return s.Session.ReplyMessage(s.message.ChannelID, s.message.ID, data)
+}
+
+func (s *messageSession) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+	// There is nothing to show while thinking, and no modal can be opened from a message
+	switch domain.InteractionResponseType(r.Type) {
+	case domain.InteractionResponseDeferredChannelMessageWithSource, domain.InteractionResponseModal:
+		return nil
+	}
+	if r.Data == nil {
+		return nil
+	}
+	return s.reply(r.Data)
+}
+
+func (s *messageSession) InteractionResponseEdit(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	return s.reply(data)
+}
+
+func (s *messageSession) FollowupMessage(i *domain.InteractionCreate, content string) error {
+	return s.reply(&domain.InteractionResponseData{Content: content})
+}
+
+func (s *messageSession) FollowupResponse(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	return s.reply(data)
+}
+
+// InteractionResponseDelete does nothing, since the replies to the message are not the responses to an interaction
+func (s *messageSession) InteractionResponseDelete(i *domain.InteractionCreate) error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMessageModeHandler_Classify(t *testing.T) {
+	handler := NewMessageModeHandler(nil, nil, nil)
+
+	tests := []struct {
+		content string
+		name    string
+		text    string
+	}{
+		{"男性は一人でしたか？", "q", "男性は一人でしたか？"},
+		{"  Was he alone?  ", "q", "Was he alone?"},
+		{"回答: 母のスープと同じ味だった", "answer", "母のスープと同じ味だった"},
+		{"回答：母のスープと同じ味だった", "answer", "母のスープと同じ味だった"},
+		{"回答:", "", ""},
+		{"難しいですね", "", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		name, text := handler.classify(tt.content)
+		if name != tt.name || text != tt.text {
+			t.Errorf("Expected %q to be classified as %q %q, got %q %q", tt.content, tt.name, tt.text, name, text)
+		}
+	}
+
+	// The marker is configurable
+	handler.SetAnswerMarker("!a")
+	if name, text := handler.classify("!a 亀のスープ"); name != "answer" || text != "亀のスープ" {
+		t.Errorf("Expected the custom marker to be accepted, got %q %q", name, text)
+	}
+}
+
+func TestMessageModeHandler_HandleMessage_Question(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{ChannelID: "channel-id"}, nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create the handler of the commands
+	commands := mock.NewMockCommandHandler(ctrl)
+
+	// The question is handled as /q, and its responses are replied to the message
+	commands.EXPECT().Handle(gomock.Any(), gomock.Any()).Do(func(s domain.Session, i *domain.InteractionCreate) {
+		if i.Data.Name != "q" || i.Data.Options[0].Value != "男性は一人でしたか？" {
+			t.Errorf("Unexpected interaction data: %+v", i.Data)
+		}
+		if err := deferResponse(s, i); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if err := editResponse(s, i, "はい"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+	mockSession.EXPECT().ReplyMessage("channel-id", "message-id", &domain.InteractionResponseData{Content: "はい"}).Return(nil)
+
+	handler := NewMessageModeHandler(commands, mockGameStore, mockLogger)
+	handler.HandleMessage(mockSession, &domain.MessageCreate{
+		ID:        "message-id",
+		ChannelID: "channel-id",
+		Content:   "男性は一人でしたか？",
+	})
+}
+
+func TestMessageModeHandler_HandleMessage_AnswerInThread(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{ChannelID: "channel-id", ThreadID: "thread-id"}, nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create the handler of the commands
+	commands := mock.NewMockCommandHandler(ctrl)
+
+	// The answer posted to the channel is handled as /answer in the thread of the game
+	commands.EXPECT().Handle(gomock.Any(), gomock.Any()).Do(func(s domain.Session, i *domain.InteractionCreate) {
+		if i.Data.Name != "answer" || i.Data.Options[0].Value != "母のスープと同じ味だった" {
+			t.Errorf("Unexpected interaction data: %+v", i.Data)
+		}
+		if i.ChannelID != "thread-id" {
+			t.Errorf("Expected the interaction in the thread, got %s", i.ChannelID)
+		}
+		if err := editResponse(s, i, "正解"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+	mockSession.EXPECT().SendMessage("thread-id", &domain.InteractionResponseData{Content: "正解"}).Return(nil)
+
+	handler := NewMessageModeHandler(commands, mockGameStore, mockLogger)
+	handler.HandleMessage(mockSession, &domain.MessageCreate{
+		ID:        "message-id",
+		ChannelID: "channel-id",
+		Content:   "回答: 母のスープと同じ味だった",
+	})
+}
+
+func TestMessageModeHandler_HandleMessage_Ignored(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{ChannelID: "channel-id"}, nil).AnyTimes()
+
+	// None of the commands is handled
+	commands := mock.NewMockCommandHandler(ctrl)
+
+	handler := NewMessageModeHandler(commands, mockGameStore, mockLogger)
+	session := mock.NewMockSession(ctrl)
+
+	// The chat, the messages of the bots and the messages outside of the game are ignored
+	handler.HandleMessage(session, &domain.MessageCreate{ChannelID: "channel-id", Content: "難しいですね"})
+	handler.HandleMessage(session, &domain.MessageCreate{ChannelID: "channel-id", Content: "男性は一人でしたか？", Bot: true})
+	handler.HandleMessage(session, &domain.MessageCreate{ChannelID: "other-channel-id", Content: "男性は一人でしたか？"})
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gong023/umi/domain"
+)
+
+// interactionName names the interaction after its command, or the custom ID of its component or modal
+func interactionName(i *domain.InteractionCreate) string {
+	switch {
+	case i.Data != nil:
+		return i.Data.Name
+	case i.Component != nil:
+		return i.Component.CustomID
+	case i.Modal != nil:
+		return i.Modal.CustomID
+	default:
+		return ""
+	}
+}
+
+// respondedSession remembers whether the interaction was responded, so that
+// the middlewares know whether to respond or to edit the response
+type respondedSession struct {
+	domain.Session
+	responded bool
+}
+
+func (s *respondedSession) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+	err := s.Session.InteractionRespond(i, r)
+	if err == nil {
+		s.responded = true
+	}
+	return err
+}
+
+// RecoverMiddleware recovers from the panic in the handler, and apologizes to
+// the user instead of leaving the interaction failed without a word
+func RecoverMiddleware(logger domain.Logger) domain.Middleware {
+	return func(next domain.CommandHandler) domain.CommandHandler {
+		return domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+			session := &respondedSession{Session: s}
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				logger.Error("Recovered from panic in %s: %v\n%s", interactionName(i), recovered, debug.Stack())
+
+				// Edit the response when it was already deferred or sent, since an interaction is responded only once
+				apology := localizerFor(i).T(msgPanicApology)
+				if session.responded {
+					if err := editResponse(s, i, apology); err != nil {
+						logger.Error("Failed to edit response: %v", err)
+					}
+					return
+				}
+
+				response := &domain.InteractionResponse{
+					Type: int(domain.InteractionResponseChannelMessageWithSource),
+					Data: &domain.InteractionResponseData{
+						Content: apology,
+						Flags:   domain.MessageFlagsEphemeral,
+					},
+				}
+				if err := s.InteractionRespond(i, response); err != nil {
+					logger.Error("Failed to respond to interaction: %v", err)
+				}
+			}()
+
+			next.Handle(session, i)
+		})
+	}
+}
+
+// LoggingMiddleware logs every request with its fields in a single line, so that the logs can be searched
+func LoggingMiddleware(logger domain.Logger) domain.Middleware {
+	return func(next domain.CommandHandler) domain.CommandHandler {
+		return domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+			logger.Info("request started id=%s type=%d name=%s user=%s channel=%s locale=%s",
+				i.ID, i.Type, interactionName(i), userIDOf(i), i.ChannelID, i.Locale)
+
+			session := &respondedSession{Session: s}
+			next.Handle(session, i)
+
+			logger.Info("request finished id=%s name=%s responded=%t", i.ID, interactionName(i), session.responded)
+		})
+	}
+}
+
+// LatencyStats is the latency of the requests of a command
+type LatencyStats struct {
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Average returns the average latency of the requests
+func (s LatencyStats) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// Latency measures how long the handlers take, by the name of the interaction
+type Latency struct {
+	logger domain.Logger
+	stats  map[string]*LatencyStats
+
+	// now is replaced in the tests
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+func NewLatency(logger domain.Logger) *Latency {
+	return &Latency{
+		logger: logger,
+		stats:  make(map[string]*LatencyStats),
+		now:    time.Now,
+	}
+}
+
+// Middleware measures the latency of every request
+func (l *Latency) Middleware() domain.Middleware {
+	return func(next domain.CommandHandler) domain.CommandHandler {
+		return domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+			start := l.now()
+			defer func() {
+				l.record(interactionName(i), l.now().Sub(start))
+			}()
+
+			next.Handle(s, i)
+		})
+	}
+}
+
+func (l *Latency) record(name string, elapsed time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats, ok := l.stats[name]
+	if !ok {
+		stats = &LatencyStats{}
+		l.stats[name] = stats
+	}
+	stats.Count++
+	stats.Total += elapsed
+	if elapsed > stats.Max {
+		stats.Max = elapsed
+	}
+
+	l.logger.Info("request latency name=%s duration=%s", name, elapsed)
+}
+
+// Stats returns the latency so far by the name of the interaction
+func (l *Latency) Stats() map[string]LatencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]LatencyStats, len(l.stats))
+	for name, s := range l.stats {
+		stats[name] = *s
+	}
+	return stats
+}
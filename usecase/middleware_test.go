@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+// recordingMiddleware appends its name to the calls before and after the handler
+func recordingMiddleware(name string, calls *[]string) domain.Middleware {
+	return func(next domain.CommandHandler) domain.CommandHandler {
+		return domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+			*calls = append(*calls, name+":before")
+			next.Handle(s, i)
+			*calls = append(*calls, name+":after")
+		})
+	}
+}
+
+func TestBotService_Use(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create the handler
+	var calls []string
+	componentHandler := mock.NewMockCommandHandler(ctrl)
+	componentHandler.EXPECT().Handle(mockSession, gomock.Any()).Do(func(s domain.Session, i *domain.InteractionCreate) {
+		calls = append(calls, "handler")
+	})
+
+	// Create the bot service
+	botService := NewBotService(mock.NewMockDiscordClient(ctrl), mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.RegisterComponent(CustomIDClue, componentHandler)
+	botService.Use(recordingMiddleware("first", &calls), recordingMiddleware("second", &calls))
+
+	botService.dispatch(mockSession, &domain.InteractionCreate{
+		Type:      int(domain.InteractionMessageComponent),
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDClue},
+	})
+
+	// The middleware registered first is the outermost
+	expected := "first:before,second:before,handler,second:after,first:after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestBotService_Handler_Message(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{ChannelID: "channel-id"}, nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create the command
+	var calls []string
+	command := mock.NewMockCommand(ctrl)
+	command.EXPECT().Definition().Return(&domain.ApplicationCommand{Name: "q"})
+	command.EXPECT().Handle(gomock.Any(), gomock.Any()).Do(func(s domain.Session, i *domain.InteractionCreate) {
+		calls = append(calls, "handler")
+	})
+
+	// Create the bot service
+	botService := NewBotService(mock.NewMockDiscordClient(ctrl), mock.NewMockOpenAIClient(ctrl), mockLogger)
+	botService.RegisterCommand(command)
+	botService.Use(recordingMiddleware("first", &calls))
+
+	// The question posted as a message runs /q through the middlewares
+	NewMessageModeHandler(botService.Handler(), mockGameStore, mockLogger).HandleMessage(mockSession, &domain.MessageCreate{
+		ID:        "message-id",
+		ChannelID: "channel-id",
+		Content:   "男性は一人でしたか？",
+	})
+
+	expected := "first:before,handler,first:after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(2)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	i := &domain.InteractionCreate{Data: &domain.ApplicationCommandInteractionData{Name: "q"}}
+
+	// The panic before the response is apologized ephemerally
+	mockSession.EXPECT().InteractionRespond(i, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != localizerFor(i).T(msgPanicApology) || r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral apology, got %+v", r.Data)
+			}
+			return nil
+		})
+	RecoverMiddleware(mockLogger)(domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+		panic("boom")
+	})).Handle(mockSession, i)
+
+	// The panic after the deferred response edits it into the apology
+	mockSession.EXPECT().InteractionRespond(i, gomock.Any()).Return(nil)
+	mockSession.EXPECT().InteractionResponseEdit(i, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+			if data.Content != localizerFor(i).T(msgPanicApology) {
+				t.Errorf("Expected the apology, got %s", data.Content)
+			}
+			return nil
+		})
+	RecoverMiddleware(mockLogger)(domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+		deferResponse(s, i)
+		panic("boom")
+	})).Handle(mockSession, i)
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil)
+
+	// Create a mock logger which records the request lines
+	var lines []string
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).Do(func(format string, args ...interface{}) {
+		lines = append(lines, format)
+	}).Times(2)
+
+	i := &domain.InteractionCreate{
+		ID:        "interaction-id",
+		ChannelID: "channel-id",
+		User:      &domain.User{ID: "player-a"},
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDClue},
+	}
+	LoggingMiddleware(mockLogger)(domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+		s.InteractionRespond(i, &domain.InteractionResponse{})
+	})).Handle(mockSession, i)
+
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "request started") || !strings.HasPrefix(lines[1], "request finished") {
+		t.Errorf("Expected the request to be logged when it starts and finishes, got %v", lines)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	now := time.Now()
+	latency := NewLatency(mockLogger)
+	latency.now = func() time.Time { return now }
+
+	// Each request takes as long as given
+	handler := latency.Middleware()(domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+		now = now.Add(time.Duration(len(i.ID)) * time.Second)
+	}))
+	command := &domain.ApplicationCommandInteractionData{Name: "q"}
+	handler.Handle(nil, &domain.InteractionCreate{ID: "a", Data: command})
+	handler.Handle(nil, &domain.InteractionCreate{ID: "abc", Data: command})
+
+	stats := latency.Stats()["q"]
+	if stats.Count != 2 || stats.Max != 3*time.Second || stats.Average() != 2*time.Second {
+		t.Errorf("Unexpected latency: %+v", stats)
+	}
+}
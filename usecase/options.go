@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"github.com/gong023/umi/domain"
+)
+
+// stringOption returns the value of the string option, or empty when the option was not given
+func stringOption(i *domain.InteractionCreate, name string) string {
+	if i.Data == nil {
+		return ""
+	}
+
+	opt := i.Data.Option(name)
+	if opt == nil {
+		return ""
+	}
+	return opt.StringValue()
+}
+
+// boolOption returns the value of the boolean option, and false as the second
+// value when the option was not given.
+func boolOption(i *domain.InteractionCreate, name string) (bool, bool) {
+	if i.Data == nil {
+		return false, false
+	}
+
+	opt := i.Data.Option(name)
+	if opt == nil {
+		return false, false
+	}
+	value, ok := opt.Value.(bool)
+	return value, ok
+}
+
+// userIDOf returns the ID of the user who used the interaction, or empty when it is unknown
+func userIDOf(i *domain.InteractionCreate) string {
+	if i.User == nil {
+		return ""
+	}
+	return i.User.ID
+}
@@ -14,13 +14,21 @@ func NewPingCommandHandler(logger domain.Logger) *PingCommandHandler {
 	}
 }
 
+func (h *PingCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name:         "ping",
+		DMPermission: true,
+	})
+}
+
 func (h *PingCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling ping command")
 
 	response := &domain.InteractionResponse{
 		Type: int(domain.InteractionResponseChannelMessageWithSource),
 		Data: &domain.InteractionResponseData{
-			Content: "はい!",
+			Content: localizerFor(i).T(msgPing),
 		},
 	}
 
@@ -38,6 +38,9 @@ type MockSession struct {
 	Interaction     *domain.InteractionCreate
 	Response        *domain.InteractionResponse
 	FollowupContent string
+	EditCalled      bool
+	EditError       error
+	EditData        *domain.InteractionResponseData
 }
 
 func (s *MockSession) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
@@ -47,6 +50,29 @@ func (s *MockSession) InteractionRespond(i *domain.InteractionCreate, r *domain.
 	return s.RespondError
 }
 
+func (s *MockSession) InteractionResponseEdit(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	s.EditCalled = true
+	s.Interaction = i
+	s.EditData = data
+	return s.EditError
+}
+
+func (s *MockSession) StartThread(channelID string, name string) (string, error) {
+	return "", nil
+}
+
+func (s *MockSession) SendMessage(channelID string, data *domain.InteractionResponseData) error {
+	return nil
+}
+
+func (s *MockSession) ArchiveThread(threadID string) error {
+	return nil
+}
+
+func (s *MockSession) ReplyMessage(channelID string, messageID string, data *domain.InteractionResponseData) error {
+	return nil
+}
+
 func (s *MockSession) FollowupMessage(i *domain.InteractionCreate, content string) error {
 	s.FollowupCalled = true
 	s.Interaction = i
@@ -54,6 +80,14 @@ func (s *MockSession) FollowupMessage(i *domain.InteractionCreate, content strin
 	return s.FollowupError
 }
 
+func (s *MockSession) FollowupResponse(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	return nil
+}
+
+func (s *MockSession) InteractionResponseDelete(i *domain.InteractionCreate) error {
+	return nil
+}
+
 func TestPingCommandHandler_Handle(t *testing.T) {
 	// Create a mock logger
 	logger := &MockLogger{}
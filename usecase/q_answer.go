@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gong023/umi/domain"
+)
+
+const (
+	qAnswerMaxAttempts = 3
+
+	// qAnswerMaxLabelLength is the maximum length of the first line. A longer
+	// line is an explanation rather than an answer, so it is not accepted.
+	qAnswerMaxLabelLength = 20
+
+	qAnswerMaxNoteLength = 60
+)
+
+const qAnswerFormatReminder = "回答の形式が正しくありません。1行目には「はい」「いいえ」「関係ありません」「部分的にはい」「重要！はい」のいずれか一つだけを書き、必要な場合のみ2行目に「補足: 」に続けて短い補足を書いてください。"
+
+// parsedQAnswer is the answer to /q parsed from the model output
+type parsedQAnswer struct {
+	Answer domain.QAnswer
+	Note   string
+}
+
+// Render formats the answer in the same way for every question
+func (a *parsedQAnswer) Render(l *localizer) string {
+	if a.Note == "" {
+		return l.T(qAnswerKey(a.Answer))
+	}
+	return l.T(msgQAnswerNote, l.T(qAnswerKey(a.Answer)), a.Note)
+}
+
+// LogLine formats the answer for the conversation history in memo/context.txt
+func (a *parsedQAnswer) LogLine() string {
+	if a.Note == "" {
+		return a.Answer.Label()
+	}
+	return fmt.Sprintf("%s 補足: %s", a.Answer.Label(), a.Note)
+}
+
+// parseQAnswer parses the model output into one of the fixed answers. The first
+// non-empty line has to be the answer, and a line starting with 補足 is taken as
+// the note. Everything else is dropped so that explanations are never posted.
+func parseQAnswer(output string) (*parsedQAnswer, bool) {
+	var answerLine string
+	var note string
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if answerLine == "" {
+			answerLine = line
+			continue
+		}
+
+		if rest, ok := cutNotePrefix(line); ok && note == "" {
+			note = rest
+		}
+	}
+
+	// The note may also follow the answer on the same line
+	if before, after, found := strings.Cut(answerLine, "補足"); found {
+		answerLine = before
+		if note == "" {
+			note = strings.TrimLeft(after, ":： ")
+		}
+	}
+
+	answer, ok := classifyQAnswer(answerLine)
+	if !ok {
+		return nil, false
+	}
+
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > qAnswerMaxNoteLength {
+		note = string([]rune(note)[:qAnswerMaxNoteLength]) + "…"
+	}
+
+	return &parsedQAnswer{Answer: answer, Note: note}, true
+}
+
+func cutNotePrefix(line string) (string, bool) {
+	for _, prefix := range []string{"補足:", "補足：", "補足 "} {
+		if rest, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+func classifyQAnswer(line string) (domain.QAnswer, bool) {
+	label := strings.Trim(strings.TrimSpace(line), "「」『』\"'*。、.!！ 　")
+	if label == "" || utf8.RuneCountInString(label) > qAnswerMaxLabelLength {
+		return "", false
+	}
+
+	// The leading word decides the answer, so that "はい、関係あります" is not irrelevant
+	lower := strings.ToLower(label)
+	switch {
+	case strings.HasPrefix(label, "重要") && strings.Contains(label, "はい"), lower == "important-yes":
+		return domain.QAnswerImportantYes, true
+	case strings.HasPrefix(label, "部分的"), strings.HasPrefix(label, "一部"), lower == "partially":
+		return domain.QAnswerPartially, true
+	case strings.HasPrefix(label, "いいえ"), lower == "no":
+		return domain.QAnswerNo, true
+	case strings.HasPrefix(label, "はい"), lower == "yes":
+		return domain.QAnswerYes, true
+	case isIrrelevantLabel(label), lower == "irrelevant":
+		return domain.QAnswerIrrelevant, true
+	}
+
+	return "", false
+}
+
+// irrelevantLabels are the labels meaning that the question does not matter
+var irrelevantLabels = map[string]bool{
+	"関係ありません": true,
+	"関係ない":    true,
+	"関係なし":    true,
+	"無関係":     true,
+	"わかりません":  true,
+	"わからない":   true,
+	"分かりません":  true,
+	"分からない":   true,
+}
+
+// isIrrelevantLabel accepts only the standalone labels, or the ones joined by slashes such as "わからない/関係ない"
+func isIrrelevantLabel(label string) bool {
+	for _, part := range strings.Split(label, "/") {
+		if !irrelevantLabels[strings.Trim(part, "「」『』\"'*。、.!！ 　")] {
+			return false
+		}
+	}
+	return true
+}
+
+// askQuestion asks the model and re-asks it while its output cannot be parsed.
+// Only the fixed answer and the note are posted, so the leak guard checks the
+// note and drops it when it reveals the solution. When no answer can be used, it
+// returns nil without an error.
+func (h *QCommandHandler) askQuestion(req *domain.ChatCompletionRequest, secrets ...string) (*parsedQAnswer, error) {
+	messages := req.Messages
+
+	for attempt := 1; attempt <= qAnswerMaxAttempts; attempt++ {
+		askReq := *req
+		askReq.Messages = messages
+
+		h.logger.Info("Sending request to OpenAI API (attempt %d)", attempt)
+		resp, err := h.openaiClient.CreateChatCompletion(&askReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("no choices in response")
+		}
+
+		output := resp.Choices[0].Message.Content
+		parsed, ok := parseQAnswer(output)
+		if !ok {
+			h.logger.Info("Failed to parse answer (attempt %d): %s", attempt, output)
+			messages = append(append([]domain.ChatMessage{}, messages...),
+				domain.ChatMessage{Role: "assistant", Content: output},
+				domain.ChatMessage{Role: "user", Content: qAnswerFormatReminder},
+			)
+			continue
+		}
+
+		if parsed.Note != "" {
+			if reason, leaked := h.leakGuard.CheckOutput(parsed.Note, secrets...); leaked {
+				h.leakGuard.RecordBlocked("q", "output", reason, parsed.Note)
+				parsed.Note = ""
+			}
+		}
+
+		return parsed, nil
+	}
+
+	return nil, nil
+}
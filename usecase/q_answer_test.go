@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseQAnswer(t *testing.T) {
+	cases := []struct {
+		output string
+		answer domain.QAnswer
+		note   string
+	}{
+		{output: "はい", answer: domain.QAnswerYes},
+		{output: "「いいえ」。", answer: domain.QAnswerNo},
+		{output: "わからない/関係ない", answer: domain.QAnswerIrrelevant},
+		{output: "関係ありません", answer: domain.QAnswerIrrelevant},
+		{output: "部分的にはい\n補足: 飲んだのは男性だけです", answer: domain.QAnswerPartially, note: "飲んだのは男性だけです"},
+		{output: "重要！はい", answer: domain.QAnswerImportantYes},
+		{output: "はい 補足：昔の話です", answer: domain.QAnswerYes, note: "昔の話です"},
+		{output: "はい\nなぜなら男性は過去に遭難していたからです。", answer: domain.QAnswerYes},
+		{output: "はい、関係あります", answer: domain.QAnswerYes},
+		{output: "いいえ、一部だけです", answer: domain.QAnswerNo},
+	}
+
+	for _, c := range cases {
+		parsed, ok := parseQAnswer(c.output)
+		if !ok {
+			t.Errorf("Expected '%s' to be parsed", c.output)
+			continue
+		}
+		if parsed.Answer != c.answer {
+			t.Errorf("Expected '%s' to be parsed as %s, got %s", c.output, c.answer, parsed.Answer)
+		}
+		if parsed.Note != c.note {
+			t.Errorf("Expected the note of '%s' to be '%s', got '%s'", c.output, c.note, parsed.Note)
+		}
+	}
+}
+
+func TestParseQAnswer_Unparsable(t *testing.T) {
+	outputs := []string{
+		"",
+		"男性はかつて遭難しており、その時に食べたスープが亀のスープではなかったことに気付いたのです。",
+		"どうでしょう",
+		"関係があるかもしれません",
+	}
+
+	for _, output := range outputs {
+		if parsed, ok := parseQAnswer(output); ok {
+			t.Errorf("Expected '%s' not to be parsed, got %+v", output, parsed)
+		}
+	}
+}
+
+func TestQCommandHandler_AskQuestion_Reask(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock OpenAI client
+	mockOpenAIClient := mock.NewMockOpenAIClient(ctrl)
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	newResponse := func(content string) *domain.ChatCompletionResponse {
+		return &domain.ChatCompletionResponse{
+			Choices: []struct {
+				Index        int                `json:"index"`
+				Message      domain.ChatMessage `json:"message"`
+				FinishReason string             `json:"finish_reason"`
+			}{
+				{Message: domain.ChatMessage{Role: "assistant", Content: content}},
+			},
+		}
+	}
+
+	// The first output is an explanation, so the model is asked again with the format
+	gomock.InOrder(
+		mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).Return(newResponse("どうでしょう、それは難しい質問ですね"), nil),
+		mockOpenAIClient.EXPECT().CreateChatCompletion(gomock.Any()).DoAndReturn(
+			func(req *domain.ChatCompletionRequest) (*domain.ChatCompletionResponse, error) {
+				last := req.Messages[len(req.Messages)-1]
+				if last.Content != qAnswerFormatReminder {
+					t.Errorf("Expected the format reminder to be sent, got '%s'", last.Content)
+				}
+				return newResponse("いいえ"), nil
+			}),
+	)
+
+	handler := NewQCommandHandler(mockOpenAIClient, nil, nil, NewLeakGuard(nil, mockLogger), mockLogger)
+	req := &domain.ChatCompletionRequest{
+		Model:    "chatgpt-4o-latest",
+		Messages: []domain.ChatMessage{{Role: "user", Content: "質問: 男性は一人でしたか？"}},
+	}
+
+	answer, err := handler.askQuestion(req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if answer == nil {
+		t.Fatalf("Expected an answer, got none")
+	}
+	if answer.Answer != domain.QAnswerNo {
+		t.Errorf("Expected the answer to be no, got %s", answer.Answer)
+	}
+}
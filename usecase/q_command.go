@@ -3,88 +3,54 @@ package usecase
 import (
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/bwmarrin/discordgo"
 	"github.com/gong023/umi/domain"
 )
 
 type QCommandHandler struct {
 	openaiClient domain.OpenAIClient
 	fileSystem   domain.FileSystem
+	gameStore    domain.GameStore
+	leakGuard    *LeakGuard
 	logger       domain.Logger
 }
 
-func NewQCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, logger domain.Logger) *QCommandHandler {
+func NewQCommandHandler(openaiClient domain.OpenAIClient, fileSystem domain.FileSystem, gameStore domain.GameStore, leakGuard *LeakGuard, logger domain.Logger) *QCommandHandler {
 	return &QCommandHandler{
 		openaiClient: openaiClient,
 		fileSystem:   fileSystem,
+		gameStore:    gameStore,
+		leakGuard:    leakGuard,
 		logger:       logger,
 	}
 }
 
+func (h *QCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "q",
+		Options: []*domain.ApplicationCommandOption{
+			{
+				Type:      domain.ApplicationCommandOptionString,
+				Name:      "message",
+				Required:  true,
+				MaxLength: 500,
+			},
+		},
+	})
+}
+
 func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling q command")
-
-	// Extract the message from the command options
-	var message string
+	l := localizerFor(i)
 
 	// Log the entire interaction data for debugging
 	h.logger.Info("Interaction data: %+v", i)
 
-	// Check if the interaction data contains the message directly
-	if i.Original != nil {
-		originalInteraction, ok := i.Original.(*discordgo.InteractionCreate)
-		if ok {
-			h.logger.Info("Original interaction: %+v", originalInteraction)
-
-			// Try to get the command data
-			if originalInteraction.Type == discordgo.InteractionApplicationCommand {
-				data := originalInteraction.ApplicationCommandData()
-				h.logger.Info("Application command data: %+v", data)
-
-				// Check if there are options
-				if len(data.Options) > 0 {
-					h.logger.Info("Command options found: %d options", len(data.Options))
-					for idx, opt := range data.Options {
-						h.logger.Info("Option %d: Name=%s, Value=%v", idx, opt.Name, opt.Value)
-					}
-
-					// Try to extract the message from the options
-					for _, opt := range data.Options {
-						if opt.Name == "message" {
-							if val, ok := opt.Value.(string); ok {
-								message = val
-								h.logger.Info("Extracted question from user: %s", message)
-							} else {
-								h.logger.Error("Failed to extract question: Value is not a string: %T", opt.Value)
-							}
-							break
-						}
-					}
-				} else {
-					h.logger.Error("No options found in ApplicationCommandData")
-				}
-			} else {
-				h.logger.Error("Interaction is not an ApplicationCommand: %d", originalInteraction.Type)
-			}
-		} else {
-			h.logger.Error("Original interaction is not of type *discordgo.InteractionCreate: %T", i.Original)
-		}
-	} else if i.Data != nil && len(i.Data.Options) > 0 {
-		h.logger.Info("Command options found in domain model: %d options", len(i.Data.Options))
-		for idx, opt := range i.Data.Options {
-			h.logger.Info("Option %d: Name=%s, Value=%v", idx, opt.Name, opt.Value)
-		}
-
-		if val, ok := i.Data.Options[0].Value.(string); ok {
-			message = val
-			h.logger.Info("Extracted question from user: %s", message)
-		} else {
-			h.logger.Error("Failed to extract question: Value is not a string: %T", i.Data.Options[0].Value)
-		}
-	} else {
-		h.logger.Error("No command options found: Data=%v", i.Data)
-	}
+	// Extract the question from the command options
+	message := stringOption(i, "message")
+	h.logger.Info("Extracted question from user: %s", message)
 
 	if message == "" {
 		h.logger.Error("No message provided in q command")
@@ -92,7 +58,7 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 		response := &domain.InteractionResponse{
 			Type: int(domain.InteractionResponseChannelMessageWithSource),
 			Data: &domain.InteractionResponseData{
-				Content: "質問を入力してください。例: `/q 男性は何を飲んでいましたか？`",
+				Content: l.T(msgQEmpty),
 			},
 		}
 		if err := s.InteractionRespond(i, response); err != nil {
@@ -101,16 +67,23 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 		return
 	}
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "質問を処理しています...",
-		},
+	// Refuse the question when it looks like a prompt injection
+	if pattern, blocked := h.leakGuard.ScreenInput(message); blocked {
+		h.leakGuard.RecordBlocked("q", "input", fmt.Sprintf("injection pattern %q", pattern), message)
+		response := &domain.InteractionResponse{
+			Type: int(domain.InteractionResponseChannelMessageWithSource),
+			Data: &domain.InteractionResponseData{
+				Content: l.T(msgQRefused),
+			},
+		}
+		if err := s.InteractionRespond(i, response); err != nil {
+			h.logger.Error("Failed to respond to interaction: %v", err)
+		}
+		return
 	}
 
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
@@ -125,6 +98,7 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 	exists, err := h.fileSystem.FileExists(contextPath)
 	if err != nil {
 		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -133,6 +107,7 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 		contextContent, err = h.fileSystem.ReadFile(contextPath)
 		if err != nil {
 			h.logger.Error("Failed to read context file: %v", err)
+			failResponse(s, i, h.logger)
 			return
 		}
 
@@ -147,11 +122,11 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 	if !quizExists {
 		h.logger.Info("No quiz found")
 		// Send a response indicating that no quiz is available
-		followupMessage := "現在クイズが存在しません。`/create` コマンドで新しいクイズを作成してください。"
+		followupMessage := l.T(msgNoQuiz)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, followupMessage); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editResponse(s, i, followupMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		h.logger.Info("No quiz available, suggesting /create command: %s", followupMessage)
@@ -163,6 +138,7 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 	promptContent, err := h.fileSystem.ReadFile(promptPath)
 	if err != nil {
 		h.logger.Error("Failed to read prompt file: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -174,6 +150,17 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 		},
 	}
 
+	// Give the stored solution to the model so that it is consistent with the other commands
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+		failResponse(s, i, h.logger)
+		return
+	}
+	if solution, ok := solutionMessage(game, false); ok {
+		messages = append(messages, solution)
+	}
+
 	// Process the conversation history and add it to the messages
 	if len(conversationHistory) > 0 {
 		// The first line is always the quiz (assistant's role)
@@ -253,25 +240,25 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 		Temperature: 0.7,
 	}
 
-	// Send the request to the OpenAI API
-	h.logger.Info("Sending request to OpenAI API")
-	resp, err := h.openaiClient.CreateChatCompletion(req)
+	// Send the request to the OpenAI API through the leak guard
+	answer, err := h.askQuestion(req, game.Solution)
 	if err != nil {
 		h.logger.Error("Failed to create chat completion: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
-	// Extract the answer from the response
-	if len(resp.Choices) == 0 {
-		h.logger.Error("No choices in response")
+	if answer == nil {
+		if err := editEmbed(s, i, qRefusalEmbed(l, message, l.T(msgQUnanswerable))); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
+		}
 		return
 	}
 
-	answer := resp.Choices[0].Message.Content
-	h.logger.Info("Received answer: %s", answer)
+	h.logger.Info("Received answer: %s", answer.LogLine())
 
 	// Format the answer
-	formattedAnswer := fmt.Sprintf("**質問**: %s\n\n**回答**: %s", message, strings.TrimSpace(answer))
+	embed := qAnswerEmbed(l, message, answer)
 
 	// Append the question and answer to the context file
 	// First, read the existing content
@@ -291,7 +278,7 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 	updatedContent += userQuestion + "\n"
 
 	// Add the assistant's answer
-	updatedContent += answer
+	updatedContent += answer.LogLine()
 
 	// Write the updated content back to the context file
 	if err := h.fileSystem.WriteFile(contextPath, []byte(updatedContent), 0644); err != nil {
@@ -301,10 +288,22 @@ func (h *QCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate)
 		h.logger.Info("Updated context file with new question and answer")
 	}
 
+	// Store the normalized answer in the game record
+	game.Questions = append(game.Questions, &domain.QuestionRecord{
+		Question: message,
+		Answer:   answer.Answer,
+		Note:     answer.Note,
+		AskedAt:  time.Now(),
+		UserID:   userIDOf(i),
+	})
+	if err := h.gameStore.Save(game); err != nil {
+		h.logger.Error("Failed to save game record: %v", err)
+	}
+
 	// Send the response with the answer
-	if err := s.FollowupMessage(i, formattedAnswer); err != nil {
-		h.logger.Error("Failed to send follow-up message: %v", err)
+	if err := editEmbed(s, i, embed); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
 	}
 
-	h.logger.Info("Answer created: %s", formattedAnswer)
+	h.logger.Info("Answer created: %s", answer.Render(l))
 }
@@ -1,85 +1,121 @@
 package usecase
 
 import (
-	"os"
-	"path/filepath"
-
 	"github.com/gong023/umi/domain"
 )
 
 type QuitCommandHandler struct {
-	logger domain.Logger
+	fileSystem domain.FileSystem
+	gameStore  domain.GameStore
+	logger     domain.Logger
+	authorizer *Authorizer
+	voting     *Voting
 }
 
-func NewQuitCommandHandler(logger domain.Logger) *QuitCommandHandler {
+func NewQuitCommandHandler(fileSystem domain.FileSystem, gameStore domain.GameStore, logger domain.Logger) *QuitCommandHandler {
 	return &QuitCommandHandler{
-		logger: logger,
+		fileSystem: fileSystem,
+		gameStore:  gameStore,
+		logger:     logger,
 	}
 }
 
+// SetAuthorizer restricts who may use the command with the policy of the authorizer
+func (h *QuitCommandHandler) SetAuthorizer(authorizer *Authorizer) {
+	h.authorizer = authorizer
+}
+
+// SetVoting makes the command start a vote of the players instead of ending the game alone
+func (h *QuitCommandHandler) SetVoting(voting *Voting) {
+	h.voting = voting
+	voting.register("quit", h)
+}
+
+func (h *QuitCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "quit",
+	})
+}
+
 func (h *QuitCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling quit command")
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "クイズを終了しています...",
-		},
-	}
+	// End the game, or start a vote, as the policy and the voting decide
+	endGame(s, i, "quit", h.authorizer, h.voting, h.finish)
+}
+
+// finish quits the game, after the vote passed when the voting is on
+func (h *QuitCommandHandler) finish(s domain.Session, i *domain.InteractionCreate) {
+	l := localizerFor(i)
 
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
 
 	// Check if a quiz exists
-	contextPath := filepath.Join("memo", "context.txt")
-	quizExists := false
+	contextPath := h.fileSystem.JoinPath("memo", "context.txt")
 
 	// Check if the context file exists
-	if _, err := os.Stat(contextPath); err == nil {
-		quizExists = true
+	quizExists, err := h.fileSystem.FileExists(contextPath)
+	if err != nil {
+		h.logger.Error("Failed to check if context file exists: %v", err)
+		failResponse(s, i, h.logger)
+		return
 	}
 
 	if !quizExists {
 		h.logger.Info("No quiz found, nothing to quit")
 
 		// Send a response indicating that no quiz is available
-		followupMessage := "現在クイズが存在しません。`/create` コマンドで新しいクイズを作成してください。"
+		followupMessage := l.T(msgNoQuiz)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, followupMessage); err != nil {
-			h.logger.Error("Failed to send follow-up message: %v", err)
+		if err := editResponse(s, i, followupMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		h.logger.Info("No quiz available, suggesting /create command: %s", followupMessage)
 		return
 	}
 
+	// Load the game record to close its thread after quitting
+	game, err := h.gameStore.Load()
+	if err != nil {
+		h.logger.Error("Failed to load game record: %v", err)
+	}
+
 	// Delete the context file
-	if err := os.Remove(contextPath); err != nil {
+	if err := h.fileSystem.RemoveFile(contextPath); err != nil {
 		h.logger.Error("Failed to delete context file: %v", err)
 
 		// Send a response indicating that the quit command failed
-		errorMessage := "クイズの終了に失敗しました。"
+		errorMessage := l.T(msgQuitFailed)
 
 		// Send the response with the message
-		if err := s.FollowupMessage(i, errorMessage); err != nil {
-			h.logger.Error("Failed to send error message: %v", err)
+		if err := editResponse(s, i, errorMessage); err != nil {
+			h.logger.Error("Failed to edit response: %v", err)
 		}
 
 		return
 	}
 
+	if err := h.gameStore.Delete(); err != nil {
+		h.logger.Error("Failed to delete game record: %v", err)
+	}
+
 	// Send a response indicating that the quit command succeeded
-	successMessage := "クイズを終了しました。新しいクイズを始めるには `/create` コマンドを使用してください。"
+	successMessage := l.T(msgQuitDone)
 
 	// Send the response with the message
-	if err := s.FollowupMessage(i, successMessage); err != nil {
-		h.logger.Error("Failed to send success message: %v", err)
+	if err := editResponse(s, i, successMessage); err != nil {
+		h.logger.Error("Failed to edit response: %v", err)
 	}
 
+	// Close the thread of the game
+	closeGameThread(s, i, game, &domain.InteractionResponseData{Content: successMessage}, h.logger)
+
 	h.logger.Info("Quiz quit successfully")
 }
@@ -38,8 +38,11 @@ func TestQuitCommandHandler_Handle(t *testing.T) {
 	// First for the initial response, second for the follow-up response
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the quit command handler
-	handler := NewQuitCommandHandler(mockLogger)
+	handler := NewQuitCommandHandler(mock.NewMockFileSystem(ctrl), mockGameStore, mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -95,8 +98,11 @@ func TestQuitCommandHandler_Handle_NoQuiz(t *testing.T) {
 	// First for the initial response, second for the follow-up response
 	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).Return(nil).Times(2)
 
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+
 	// Create the quit command handler
-	handler := NewQuitCommandHandler(mockLogger)
+	handler := NewQuitCommandHandler(mock.NewMockFileSystem(ctrl), mockGameStore, mockLogger)
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
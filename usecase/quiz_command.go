@@ -19,19 +19,19 @@ func NewQuizCommandHandler(openaiClient domain.OpenAIClient, logger domain.Logge
 	}
 }
 
+func (h *QuizCommandHandler) Definition() *domain.ApplicationCommand {
+	// The descriptions are filled from the message catalog
+	return describeCommand(&domain.ApplicationCommand{
+		Name: "quiz",
+	})
+}
+
 func (h *QuizCommandHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
 	h.logger.Info("Handling quiz command")
+	l := localizerFor(i)
 
-	// Create a response to acknowledge the command
-	response := &domain.InteractionResponse{
-		Type: int(domain.InteractionResponseChannelMessageWithSource),
-		Data: &domain.InteractionResponseData{
-			Content: "クイズを考えています...",
-		},
-	}
-
-	// Send the initial response
-	if err := s.InteractionRespond(i, response); err != nil {
+	// Defer the response so that Discord shows "thinking…" until the response is edited
+	if err := deferResponse(s, i); err != nil {
 		h.logger.Error("Failed to respond to interaction: %v", err)
 		return
 	}
@@ -57,12 +57,14 @@ func (h *QuizCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	resp, err := h.openaiClient.CreateChatCompletion(req)
 	if err != nil {
 		h.logger.Error("Failed to create chat completion: %v", err)
+		failResponse(s, i, h.logger)
 		return
 	}
 
 	// Extract the quiz from the response
 	if len(resp.Choices) == 0 {
 		h.logger.Error("No choices in response")
+		failResponse(s, i, h.logger)
 		return
 	}
 
@@ -70,7 +72,7 @@ func (h *QuizCommandHandler) Handle(s domain.Session, i *domain.InteractionCreat
 	h.logger.Info("Received quiz: %s", quiz)
 
 	// Format the quiz
-	formattedQuiz := fmt.Sprintf("**新しいウミガメのスープクイズ**\n\n%s", strings.TrimSpace(quiz))
+	formattedQuiz := fmt.Sprintf("**%s**\n\n%s", l.T(msgPuzzleNewTitle), strings.TrimSpace(quiz))
 
 	// Create a follow-up message with the quiz
 	// Note: In a real implementation, you would need to use the Discord API to send a follow-up message
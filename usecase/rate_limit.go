@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gong023/umi/domain"
+)
+
+// questionWindow is the window the questions per game channel are counted in
+const questionWindow = time.Minute
+
+// Cooldown is how long a command can not be used again after it was used
+type Cooldown struct {
+	// PerUser is the cooldown of the user who used the command
+	PerUser time.Duration
+
+	// PerChannel is the cooldown of everyone in the channel the command was used in
+	PerChannel time.Duration
+}
+
+// RateLimiter limits how often the commands are used, so that a user can not
+// flood the commands calling the OpenAI API. It is put in front of the command
+// handlers, and tells the user ephemerally when the command can be used again.
+type RateLimiter struct {
+	logger    domain.Logger
+	cooldowns map[string]*Cooldown
+
+	// maxQuestions is the number of the questions per minute in a game, or zero for no limit
+	maxQuestions int
+
+	// until is when the command can be used again, by the command and the user or the channel
+	until map[string]time.Time
+
+	// questions are when the recent questions were asked, by the channel the game is played in
+	questions map[string][]time.Time
+
+	// now is replaced in the tests
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+func NewRateLimiter(logger domain.Logger) *RateLimiter {
+	return &RateLimiter{
+		logger:    logger,
+		cooldowns: make(map[string]*Cooldown),
+		until:     make(map[string]time.Time),
+		questions: make(map[string][]time.Time),
+		now:       time.Now,
+	}
+}
+
+// SetCooldown sets the cooldown of the command
+func (r *RateLimiter) SetCooldown(command string, cooldown *Cooldown) {
+	r.cooldowns[command] = cooldown
+}
+
+// SetQuestionLimit sets the number of the questions per minute in a game, or zero for no limit
+func (r *RateLimiter) SetQuestionLimit(perMinute int) {
+	r.maxQuestions = perMinute
+}
+
+// LimitCommand puts the limits of the command in front of it
+func (r *RateLimiter) LimitCommand(command domain.Command) domain.Command {
+	return &limitedCommand{
+		Command: command,
+		handler: r.Limit(command.Definition().Name, command),
+	}
+}
+
+// Limit puts the limits of the command in front of the handler, such as the
+// button running the command. The handler shares the cooldown of the command.
+func (r *RateLimiter) Limit(command string, handler domain.CommandHandler) domain.CommandHandler {
+	return &limitedHandler{limiter: r, command: command, handler: handler}
+}
+
+// allow records the use of the command and returns true when it is not limited.
+// Otherwise it returns when the command can be used again and the message key telling it.
+func (r *RateLimiter) allow(command string, i *domain.InteractionCreate) (bool, time.Time, messageKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+
+	// Forget the cooldowns already over
+	for key, until := range r.until {
+		if !now.Before(until) {
+			delete(r.until, key)
+		}
+	}
+
+	// Forget the questions out of the window, and the channels without the recent questions
+	for channelID, questions := range r.questions {
+		recent := questions[:0]
+		for _, askedAt := range questions {
+			if now.Sub(askedAt) < questionWindow {
+				recent = append(recent, askedAt)
+			}
+		}
+		if len(recent) == 0 {
+			delete(r.questions, channelID)
+			continue
+		}
+		r.questions[channelID] = recent
+	}
+
+	if command == "q" && r.maxQuestions > 0 {
+		if questions := r.questions[i.ChannelID]; len(questions) >= r.maxQuestions {
+			return false, questions[0].Add(questionWindow), msgRateLimitQuestions
+		}
+	}
+
+	cooldown := r.cooldowns[command]
+	userKey := command + "/user/" + userIDOf(i)
+	channelKey := command + "/channel/" + i.ChannelID
+	if cooldown != nil {
+		if until, ok := r.until[userKey]; ok {
+			return false, until, msgRateLimitUser
+		}
+		if until, ok := r.until[channelKey]; ok {
+			return false, until, msgRateLimitChannel
+		}
+	}
+
+	// Record the use of the command
+	if command == "q" && r.maxQuestions > 0 {
+		r.questions[i.ChannelID] = append(r.questions[i.ChannelID], now)
+	}
+	if cooldown != nil {
+		if cooldown.PerUser > 0 && userIDOf(i) != "" {
+			r.until[userKey] = now.Add(cooldown.PerUser)
+		}
+		if cooldown.PerChannel > 0 && i.ChannelID != "" {
+			r.until[channelKey] = now.Add(cooldown.PerChannel)
+		}
+	}
+
+	return true, time.Time{}, ""
+}
+
+// Middleware applies the limits to the application commands, as an alternative
+// to wrapping each command with LimitCommand
+func (r *RateLimiter) Middleware() domain.Middleware {
+	return func(next domain.CommandHandler) domain.CommandHandler {
+		return domain.HandlerFunc(func(s domain.Session, i *domain.InteractionCreate) {
+			if i.Data == nil {
+				next.Handle(s, i)
+				return
+			}
+			r.Limit(i.Data.Name, next).Handle(s, i)
+		})
+	}
+}
+
+// limitedHandler checks the limits of the command before handling the interaction
+type limitedHandler struct {
+	limiter *RateLimiter
+	command string
+	handler domain.CommandHandler
+}
+
+func (h *limitedHandler) Handle(s domain.Session, i *domain.InteractionCreate) {
+	// Opening the modal does not use up the cooldown, which is counted when the modal is submitted
+	if opensAnswerModal(i) {
+		h.handler.Handle(s, i)
+		return
+	}
+
+	allowed, until, key := h.limiter.allow(h.command, i)
+	if allowed {
+		h.handler.Handle(s, i)
+		return
+	}
+
+	h.limiter.logger.Info("Rate limited %s command until %s", h.command, until.Format(time.RFC3339))
+	l := localizerFor(i)
+
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseChannelMessageWithSource),
+		Data: &domain.InteractionResponseData{
+			Content: l.T(key, l.Command(h.command), until.Unix()),
+			Flags:   domain.MessageFlagsEphemeral,
+		},
+	}
+	if err := s.InteractionRespond(i, response); err != nil {
+		h.limiter.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// limitedCommand is the command with the limits, which keeps the definition of the command
+type limitedCommand struct {
+	domain.Command
+	handler domain.CommandHandler
+}
+
+func (c *limitedCommand) Handle(s domain.Session, i *domain.InteractionCreate) {
+	c.handler.Handle(s, i)
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRateLimiter_Cooldown(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(nil)
+	limiter.now = func() time.Time { return now }
+	limiter.SetCooldown("clue", &Cooldown{PerUser: time.Minute, PerChannel: 10 * time.Second})
+
+	playerA := &domain.InteractionCreate{ChannelID: "channel-a", User: &domain.User{ID: "player-a"}}
+	playerB := &domain.InteractionCreate{ChannelID: "channel-a", User: &domain.User{ID: "player-b"}}
+	otherChannel := &domain.InteractionCreate{ChannelID: "channel-b", User: &domain.User{ID: "player-b"}}
+
+	if allowed, _, _ := limiter.allow("clue", playerA); !allowed {
+		t.Fatalf("Expected the first clue to be allowed")
+	}
+
+	// The user and the channel are cooling down
+	if allowed, until, key := limiter.allow("clue", playerA); allowed || key != msgRateLimitUser || !until.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected the user cooldown, got %v %s %s", allowed, until, key)
+	}
+	if allowed, _, key := limiter.allow("clue", playerB); allowed || key != msgRateLimitChannel {
+		t.Errorf("Expected the channel cooldown, got %v %s", allowed, key)
+	}
+	if allowed, _, _ := limiter.allow("clue", otherChannel); !allowed {
+		t.Errorf("Expected the clue in the other channel to be allowed")
+	}
+
+	// The other commands have no cooldown
+	if allowed, _, _ := limiter.allow("info", playerA); !allowed {
+		t.Errorf("Expected the command without the cooldown to be allowed")
+	}
+
+	// The channel cooldown is over before the user one
+	now = now.Add(30 * time.Second)
+	if allowed, _, _ := limiter.allow("clue", &domain.InteractionCreate{ChannelID: "channel-a", User: &domain.User{ID: "player-c"}}); !allowed {
+		t.Errorf("Expected the channel cooldown to be over")
+	}
+	if allowed, _, _ := limiter.allow("clue", playerA); allowed {
+		t.Errorf("Expected the user cooldown to remain")
+	}
+}
+
+func TestRateLimiter_QuestionLimit(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(nil)
+	limiter.now = func() time.Time { return now }
+	limiter.SetQuestionLimit(2)
+
+	for n := 0; n < 2; n++ {
+		i := &domain.InteractionCreate{ChannelID: "channel-a", User: &domain.User{ID: "player-" + string(rune('a'+n))}}
+		if allowed, _, _ := limiter.allow("q", i); !allowed {
+			t.Fatalf("Expected the question %d to be allowed", n+1)
+		}
+		now = now.Add(10 * time.Second)
+	}
+
+	// The limit is shared by everyone in the game
+	if allowed, until, key := limiter.allow("q", &domain.InteractionCreate{ChannelID: "channel-a", User: &domain.User{ID: "player-c"}}); allowed || key != msgRateLimitQuestions || !until.Equal(now.Add(40*time.Second)) {
+		t.Errorf("Expected the question limit until the first question leaves the window, got %v %s %s", allowed, until, key)
+	}
+
+	// The games in the other channels have their own limits
+	if allowed, _, _ := limiter.allow("q", &domain.InteractionCreate{ChannelID: "channel-b", User: &domain.User{ID: "player-c"}}); !allowed {
+		t.Errorf("Expected the question in the other channel to be allowed")
+	}
+
+	// The questions older than a minute are not counted
+	now = now.Add(40 * time.Second)
+	if allowed, _, _ := limiter.allow("q", &domain.InteractionCreate{ChannelID: "channel-a"}); !allowed {
+		t.Errorf("Expected the question to be allowed after the window")
+	}
+}
+
+func TestRateLimiter_LimitCommand(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create a mock command
+	command := mock.NewMockCommand(ctrl)
+	command.EXPECT().Definition().Return(&domain.ApplicationCommand{Name: "q"}).AnyTimes()
+
+	limiter := NewRateLimiter(mockLogger)
+	limiter.SetCooldown("q", &Cooldown{PerUser: time.Minute})
+	limited := limiter.LimitCommand(command)
+
+	// The definition is kept
+	if limited.Definition().Name != "q" {
+		t.Errorf("Expected the definition of the command, got %s", limited.Definition().Name)
+	}
+
+	i := &domain.InteractionCreate{User: &domain.User{ID: "player-a"}}
+
+	// The first question is handled, and the second one is told ephemerally when to ask again
+	command.EXPECT().Handle(mockSession, i)
+	mockSession.EXPECT().InteractionRespond(i, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral response, got %+v", r.Data)
+			}
+			return nil
+		})
+
+	limited.Handle(mockSession, i)
+	limited.Handle(mockSession, i)
+}
+
+func TestRateLimiter_AnswerModal(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// Create a mock handler for the answer command and its modal
+	handler := mock.NewMockCommandHandler(ctrl)
+
+	limiter := NewRateLimiter(mockLogger)
+	limiter.SetCooldown("answer", &Cooldown{PerUser: time.Minute})
+	command := limiter.Middleware()(handler)
+	modal := limiter.Limit("answer", handler)
+
+	user := &domain.User{ID: "player-a"}
+	slash := &domain.InteractionCreate{User: user, Data: &domain.ApplicationCommandInteractionData{Name: "answer"}}
+	submit := &domain.InteractionCreate{User: user, Modal: &domain.ModalSubmitInteractionData{CustomID: CustomIDAnswerModal}}
+
+	// Opening the modal twice does not use up the cooldown, and the first answer submitted is judged
+	handler.EXPECT().Handle(mockSession, slash).Times(2)
+	handler.EXPECT().Handle(mockSession, submit)
+
+	// The second answer is told ephemerally when to answer again
+	mockSession.EXPECT().InteractionRespond(submit, gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral response, got %+v", r.Data)
+			}
+			return nil
+		})
+
+	command.Handle(mockSession, slash)
+	modal.Handle(mockSession, submit)
+	command.Handle(mockSession, slash)
+	modal.Handle(mockSession, submit)
+}
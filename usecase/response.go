@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"github.com/gong023/umi/domain"
+)
+
+// deferResponse acknowledges the interaction so that Discord shows "thinking…"
+// until the response is edited with editResponse.
+func deferResponse(s domain.Session, i *domain.InteractionCreate) error {
+	return s.InteractionRespond(i, &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseDeferredChannelMessageWithSource),
+	})
+}
+
+// deferEphemeralResponse is deferResponse for the response shown only to the user.
+func deferEphemeralResponse(s domain.Session, i *domain.InteractionCreate) error {
+	return s.InteractionRespond(i, &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseDeferredChannelMessageWithSource),
+		Data: &domain.InteractionResponseData{
+			Flags: domain.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// editResponse replaces the deferred response with the content.
+func editResponse(s domain.Session, i *domain.InteractionCreate, content string) error {
+	return s.InteractionResponseEdit(i, &domain.InteractionResponseData{
+		Content: content,
+	})
+}
+
+// failResponse edits the deferred response into a failure message so that it is
+// not left thinking when the handler gives up.
+func failResponse(s domain.Session, i *domain.InteractionCreate, logger domain.Logger) {
+	if err := editResponse(s, i, localizerFor(i).T(msgFailure)); err != nil {
+		logger.Error("Failed to edit response: %v", err)
+	}
+}
+
+// editEmbed replaces the deferred response with the embed and the rows of components.
+func editEmbed(s domain.Session, i *domain.InteractionCreate, embed *domain.MessageEmbed, rows ...*domain.ActionsRow) error {
+	return s.InteractionResponseEdit(i, &domain.InteractionResponseData{
+		Embeds:     []*domain.MessageEmbed{embed},
+		Components: rows,
+	})
+}
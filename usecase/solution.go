@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// generatedQuiz is the quiz generated on /create together with its solution
+type generatedQuiz struct {
+	Puzzle    string   `json:"puzzle"`
+	Solution  string   `json:"solution"`
+	KeyPoints []string `json:"key_points"`
+}
+
+func parseGeneratedQuiz(content string) (*generatedQuiz, error) {
+	// Tolerate the JSON being wrapped in a markdown code block
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var quiz generatedQuiz
+	if err := json.Unmarshal([]byte(content), &quiz); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal generated quiz: %w", err)
+	}
+
+	quiz.Puzzle = strings.TrimSpace(quiz.Puzzle)
+	quiz.Solution = strings.TrimSpace(quiz.Solution)
+	if quiz.Puzzle == "" || quiz.Solution == "" {
+		return nil, fmt.Errorf("generated quiz has no puzzle or solution")
+	}
+
+	return &quiz, nil
+}
+
+// solutionMessage returns the system message which gives the stored solution to
+// the model, so that every command is judged against the same truth. It returns
+// false when the game has no stored solution.
+func solutionMessage(game *domain.Game, reveal bool) (domain.ChatMessage, bool) {
+	if game == nil || game.Solution == "" {
+		return domain.ChatMessage{}, false
+	}
+
+	var b strings.Builder
+	if reveal {
+		b.WriteString("このクイズの正解は以下の通りです。この正解に基づいて説明してください。\n")
+	} else {
+		b.WriteString("このクイズの正解は以下の通りです。この正解に基づいて判断し、プレイヤーには正解を絶対に明かさないでください。\n")
+	}
+	b.WriteString("正解: " + game.Solution)
+
+	if len(game.KeyPoints) > 0 {
+		b.WriteString("\n正解の要素:")
+		for _, point := range game.KeyPoints {
+			b.WriteString("\n- " + point)
+		}
+	}
+
+	return domain.ChatMessage{Role: "system", Content: b.String()}, true
+}
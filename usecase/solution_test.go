@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gong023/umi/domain"
+)
+
+func TestParseGeneratedQuiz(t *testing.T) {
+	content := "```json\n{\"puzzle\": \"男性はスープを飲んで泣きました。なぜ？\", \"solution\": \"母のスープと同じ味だった。\", \"key_points\": [\"母は亡くなっている\"]}\n```"
+
+	quiz, err := parseGeneratedQuiz(content)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if quiz.Puzzle != "男性はスープを飲んで泣きました。なぜ？" {
+		t.Errorf("Unexpected puzzle: %s", quiz.Puzzle)
+	}
+	if quiz.Solution != "母のスープと同じ味だった。" {
+		t.Errorf("Unexpected solution: %s", quiz.Solution)
+	}
+	if len(quiz.KeyPoints) != 1 {
+		t.Errorf("Expected 1 key point, got %d", len(quiz.KeyPoints))
+	}
+}
+
+func TestParseGeneratedQuiz_NoSolution(t *testing.T) {
+	if _, err := parseGeneratedQuiz(`{"puzzle": "男性はスープを飲んで泣きました。なぜ？"}`); err == nil {
+		t.Error("Expected an error for a quiz without a solution")
+	}
+	if _, err := parseGeneratedQuiz("男性はスープを飲んで泣きました。なぜ？"); err == nil {
+		t.Error("Expected an error for a quiz which is not JSON")
+	}
+}
+
+func TestSolutionMessage(t *testing.T) {
+	if _, ok := solutionMessage(&domain.Game{}, false); ok {
+		t.Error("Expected no message for a game without a solution")
+	}
+
+	game := &domain.Game{
+		Solution:  "母のスープと同じ味だった。",
+		KeyPoints: []string{"母は亡くなっている"},
+	}
+
+	message, ok := solutionMessage(game, false)
+	if !ok {
+		t.Fatal("Expected a message for a game with a solution")
+	}
+	if message.Role != "system" {
+		t.Errorf("Expected the message to be a system message, got %s", message.Role)
+	}
+	if !strings.Contains(message.Content, game.Solution) || !strings.Contains(message.Content, game.KeyPoints[0]) {
+		t.Errorf("Expected the message to contain the solution and the key points, got '%s'", message.Content)
+	}
+	if !strings.Contains(message.Content, "明かさない") {
+		t.Errorf("Expected the message to forbid revealing the solution, got '%s'", message.Content)
+	}
+}
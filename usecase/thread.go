@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/gong023/umi/domain"
+)
+
+// maxThreadName is the length Discord allows for the name of a thread
+const maxThreadName = 100
+
+// threadName names the thread after the first line of the puzzle
+func threadName(puzzle string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(puzzle), "\n")
+	return truncateRunes("🐢 "+strings.Trim(line, "*# "), maxThreadName)
+}
+
+// startGameThread starts the thread of the game and posts the puzzle there.
+// It returns the ID of the thread.
+func startGameThread(s domain.Session, i *domain.InteractionCreate, puzzle *domain.MessageEmbed, rows []*domain.ActionsRow) (string, error) {
+	threadID, err := s.StartThread(i.ChannelID, threadName(puzzle.Description))
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.SendMessage(threadID, &domain.InteractionResponseData{
+		Embeds:     []*domain.MessageEmbed{puzzle},
+		Components: rows,
+	}); err != nil {
+		return "", err
+	}
+
+	return threadID, nil
+}
+
+// closeGameThread archives and locks the thread of the finished game. The final
+// message is posted to the thread as well when the command was used outside of it.
+func closeGameThread(s domain.Session, i *domain.InteractionCreate, game *domain.Game, final *domain.InteractionResponseData, logger domain.Logger) {
+	if game == nil || game.ThreadID == "" {
+		return
+	}
+
+	if i.ChannelID != game.ThreadID {
+		if err := s.SendMessage(game.ThreadID, final); err != nil {
+			logger.Error("Failed to send the final message to the thread: %v", err)
+		}
+	}
+
+	if err := s.ArchiveThread(game.ThreadID); err != nil {
+		logger.Error("Failed to archive thread: %v", err)
+		return
+	}
+
+	logger.Info("Archived the thread of the game: %s", game.ThreadID)
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestThreadName(t *testing.T) {
+	name := threadName("**男性が海辺で亀のスープを飲んでいました。**\n彼は一口飲んだ後、自殺しました。")
+	if name != "🐢 男性が海辺で亀のスープを飲んでいました。" {
+		t.Errorf("Expected the first line of the puzzle, got %s", name)
+	}
+
+	long := threadName(strings.Repeat("あ", maxThreadName*2))
+	if length := utf8.RuneCountInString(long); length > maxThreadName {
+		t.Errorf("Expected the name within %d characters, got %d", maxThreadName, length)
+	}
+}
+
+func TestStartGameThread(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	// The thread is started in the channel of the command, and the puzzle is posted there
+	puzzle := puzzleEmbed("新しいクイズ", "男性が亀のスープを飲みました。", "")
+	mockSession.EXPECT().StartThread("channel-id", gomock.Any()).Return("thread-id", nil)
+	mockSession.EXPECT().SendMessage("thread-id", gomock.Any()).DoAndReturn(
+		func(channelID string, data *domain.InteractionResponseData) error {
+			if len(data.Embeds) != 1 || data.Embeds[0] != puzzle {
+				t.Errorf("Expected the puzzle to be posted to the thread, got %+v", data.Embeds)
+			}
+			return nil
+		})
+
+	threadID, err := startGameThread(mockSession, &domain.InteractionCreate{ChannelID: "channel-id"}, puzzle, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if threadID != "thread-id" {
+		t.Errorf("Expected thread-id, got %s", threadID)
+	}
+}
+
+func TestStartGameThread_Error(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+	mockSession.EXPECT().StartThread(gomock.Any(), gomock.Any()).Return("", errors.New("missing permission"))
+
+	if _, err := startGameThread(mockSession, &domain.InteractionCreate{}, puzzleEmbed("新しいクイズ", "問題", ""), nil); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+}
+
+func TestCloseGameThread(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock logger
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	game := &domain.Game{ThreadID: "thread-id"}
+	final := &domain.InteractionResponseData{Content: "終了しました"}
+
+	// Used outside of the thread, the final message is posted to the thread before archiving
+	gomock.InOrder(
+		mockSession.EXPECT().SendMessage("thread-id", final).Return(nil),
+		mockSession.EXPECT().ArchiveThread("thread-id").Return(nil),
+	)
+	closeGameThread(mockSession, &domain.InteractionCreate{ChannelID: "channel-id"}, game, final, mockLogger)
+
+	// Used in the thread, the thread is only archived
+	mockSession.EXPECT().ArchiveThread("thread-id").Return(nil)
+	closeGameThread(mockSession, &domain.InteractionCreate{ChannelID: "thread-id"}, game, final, mockLogger)
+
+	// Nothing happens without a thread
+	closeGameThread(mockSession, &domain.InteractionCreate{ChannelID: "channel-id"}, &domain.Game{}, final, mockLogger)
+	closeGameThread(mockSession, &domain.InteractionCreate{ChannelID: "channel-id"}, nil, final, mockLogger)
+}
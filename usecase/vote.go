@@ -0,0 +1,430 @@
+package usecase
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gong023/umi/domain"
+)
+
+const (
+	// defaultVoteShare is the share of the recent participants needed to pass a vote
+	defaultVoteShare = 0.5
+
+	// defaultVoteDuration is how long a vote is open
+	defaultVoteDuration = 3 * time.Minute
+
+	// defaultVoteWindow is how recently the players have to take part to be counted as the participants
+	defaultVoteWindow = 30 * time.Minute
+)
+
+// finisher is a command ending the game which can be decided by a vote
+type finisher interface {
+	finish(s domain.Session, i *domain.InteractionCreate)
+}
+
+// Voting decides the commands ending the game, such as /giveup and /quit, by
+// the vote of the players. The vote is started by the command, and the players
+// vote with the buttons on the vote message. The vote passes when the share of
+// the recent participants agree, and is rejected once it can no longer pass. It
+// expires otherwise. The vote is kept in the game record, so that it survives the
+// restarts of the bot, which resume its expiry with Resume.
+type Voting struct {
+	gameStore domain.GameStore
+	logger    domain.Logger
+	commands  map[string]finisher
+	share     float64
+	duration  time.Duration
+	window    time.Duration
+
+	// now and afterFunc are replaced in the tests
+	now       func() time.Time
+	afterFunc func(d time.Duration, f func())
+
+	// mu serializes the votes, which read and write the game record
+	mu sync.Mutex
+}
+
+func NewVoting(gameStore domain.GameStore, logger domain.Logger) *Voting {
+	return &Voting{
+		gameStore: gameStore,
+		logger:    logger,
+		commands:  make(map[string]finisher),
+		share:     defaultVoteShare,
+		duration:  defaultVoteDuration,
+		window:    defaultVoteWindow,
+		now:       time.Now,
+		afterFunc: func(d time.Duration, f func()) { time.AfterFunc(d, f) },
+	}
+}
+
+// SetShare sets the share of the recent participants needed to pass a vote
+func (v *Voting) SetShare(share float64) {
+	v.share = share
+}
+
+// SetDuration sets how long a vote is open
+func (v *Voting) SetDuration(duration time.Duration) {
+	v.duration = duration
+}
+
+// SetWindow sets how recently the players have to take part to be counted as the participants
+func (v *Voting) SetWindow(window time.Duration) {
+	v.window = window
+}
+
+func (v *Voting) register(command string, f finisher) {
+	v.commands[command] = f
+}
+
+// participants counts the users who asked a question or a clue recently, including the user starting the vote
+func (v *Voting) participants(game *domain.Game, starter string) int {
+	since := v.now().Add(-v.window)
+	users := map[string]bool{starter: true}
+
+	for _, q := range game.Questions {
+		if q.UserID != "" && q.AskedAt.After(since) {
+			users[q.UserID] = true
+		}
+	}
+	for _, clue := range game.Clues {
+		if clue.UserID != "" && clue.GivenAt.After(since) {
+			users[clue.UserID] = true
+		}
+	}
+
+	return len(users)
+}
+
+// needed returns the number of the votes needed to pass a vote
+func (v *Voting) needed(participants int) int {
+	needed := int(math.Ceil(v.share * float64(participants)))
+	if needed < 1 {
+		needed = 1
+	}
+	return needed
+}
+
+// castVote records the vote of the user, who can change it until the vote ends
+func castVote(vote *domain.VoteRecord, userID string, yes bool) {
+	remove := func(users []string) []string {
+		kept := users[:0]
+		for _, user := range users {
+			if user != userID {
+				kept = append(kept, user)
+			}
+		}
+		return kept
+	}
+
+	vote.Yes = remove(vote.Yes)
+	vote.No = remove(vote.No)
+	if yes {
+		vote.Yes = append(vote.Yes, userID)
+	} else {
+		vote.No = append(vote.No, userID)
+	}
+}
+
+// Start starts the vote for the command. The user starting the vote votes for
+// it, so the command is run at once when the vote is not needed, such as when
+// the user is playing alone.
+func (v *Voting) Start(s domain.Session, i *domain.InteractionCreate, command string) {
+	v.logger.Info("Starting vote for %s command", command)
+
+	// Run the command outside of the lock, since it takes a while
+	if v.start(s, i, command) {
+		v.commands[command].finish(s, i)
+	}
+}
+
+// start records the vote for the command, and returns true when the vote passed
+func (v *Voting) start(s domain.Session, i *domain.InteractionCreate, command string) bool {
+	l := localizerFor(i)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	game, err := v.gameStore.Load()
+	if err != nil {
+		v.logger.Error("Failed to load game record: %v", err)
+		v.respondEphemeral(s, i, l.T(msgFailure))
+		return false
+	}
+
+	// Only the game created by /create, which has the solution, can be ended
+	if game.Solution == "" {
+		v.logger.Info("No quiz found, not starting vote for %s command", command)
+		v.respondEphemeral(s, i, l.T(msgNoQuiz))
+		return false
+	}
+
+	// Clear the expired vote
+	if game.Vote != nil && !v.now().Before(game.Vote.ExpiresAt) {
+		game.Vote = nil
+	}
+
+	vote := game.Vote
+	if vote != nil && vote.Command != command {
+		v.respondEphemeral(s, i, l.T(msgVoteBusy, l.Command(vote.Command)))
+		return false
+	}
+
+	// Using the command again during its vote is a vote for it
+	if vote == nil {
+		participants := v.participants(game, userIDOf(i))
+		vote = &domain.VoteRecord{
+			Command:   command,
+			Needed:    v.needed(participants),
+			Voters:    participants,
+			ChannelID: i.ChannelID,
+			ExpiresAt: v.now().Add(v.duration),
+		}
+	}
+	castVote(vote, userIDOf(i), true)
+
+	if len(vote.Yes) >= vote.Needed {
+		v.logger.Info("Vote for %s command passed: %d/%d", command, len(vote.Yes), vote.Needed)
+		if game.Vote != nil {
+			game.Vote = nil
+			if err := v.gameStore.Save(game); err != nil {
+				v.logger.Error("Failed to save game record: %v", err)
+			}
+		}
+		return true
+	}
+
+	started := game.Vote == nil
+	game.Vote = vote
+	if err := v.gameStore.Save(game); err != nil {
+		v.logger.Error("Failed to save game record: %v", err)
+		v.respondEphemeral(s, i, l.T(msgFailure))
+		return false
+	}
+
+	if !started {
+		v.respondEphemeral(s, i, l.T(msgVoteRecorded, len(vote.Yes), vote.Needed))
+		return false
+	}
+
+	// Post the vote with the buttons
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseChannelMessageWithSource),
+		Data: v.status(l, vote),
+	}
+	if err := s.InteractionRespond(i, response); err != nil {
+		v.logger.Error("Failed to respond to interaction: %v", err)
+	}
+
+	// Tell the channel when the vote expires without the interactions
+	expiresAt := vote.ExpiresAt
+	v.afterFunc(v.duration, func() {
+		v.expire(s, l, expiresAt)
+	})
+
+	return false
+}
+
+// Handle records the vote from the buttons on the vote message
+func (v *Voting) Handle(s domain.Session, i *domain.InteractionCreate) {
+	v.logger.Info("Handling vote")
+
+	// Run the command outside of the lock, since it takes a while
+	// The interaction is already responded by the update, so the result is posted to the channel
+	if command, passed := v.cast(s, i); passed {
+		v.commands[command].finish(&channelSession{Session: s, channelID: i.ChannelID}, i)
+	}
+}
+
+// cast records the vote from the button, and returns the command when the vote passed
+func (v *Voting) cast(s domain.Session, i *domain.InteractionCreate) (string, bool) {
+	l := localizerFor(i)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	game, err := v.gameStore.Load()
+	if err != nil {
+		v.logger.Error("Failed to load game record: %v", err)
+		v.respondEphemeral(s, i, l.T(msgFailure))
+		return "", false
+	}
+
+	vote := game.Vote
+	if vote == nil {
+		v.respondEphemeral(s, i, l.T(msgVoteNone))
+		return "", false
+	}
+
+	// The expired vote is closed by the first vote after its deadline
+	if !v.now().Before(vote.ExpiresAt) {
+		v.logger.Info("Vote for %s command expired", vote.Command)
+		game.Vote = nil
+		if err := v.gameStore.Save(game); err != nil {
+			v.logger.Error("Failed to save game record: %v", err)
+		}
+		v.updateMessage(s, i, &domain.InteractionResponseData{Content: l.T(msgVoteExpired, l.Command(vote.Command))})
+		return "", false
+	}
+
+	castVote(vote, userIDOf(i), i.Component != nil && i.Component.CustomID == CustomIDVoteYes)
+
+	// End the vote once the eligible voters left can no longer pass it, such as when all of them voted against it
+	// The votes kept before the voters were recorded wait for their expiry
+	if vote.Voters > 0 && vote.Voters-len(vote.No) < vote.Needed {
+		v.logger.Info("Vote for %s command rejected: %d against", vote.Command, len(vote.No))
+		game.Vote = nil
+		if err := v.gameStore.Save(game); err != nil {
+			v.logger.Error("Failed to save game record: %v", err)
+		}
+		v.updateMessage(s, i, &domain.InteractionResponseData{Content: l.T(msgVoteRejected, l.Command(vote.Command), len(vote.No))})
+		return "", false
+	}
+
+	if len(vote.Yes) < vote.Needed {
+		if err := v.gameStore.Save(game); err != nil {
+			v.logger.Error("Failed to save game record: %v", err)
+		}
+		v.updateMessage(s, i, v.status(l, vote))
+		return "", false
+	}
+
+	// Run the command once the vote passed
+	v.logger.Info("Vote for %s command passed: %d/%d", vote.Command, len(vote.Yes), vote.Needed)
+	game.Vote = nil
+	if err := v.gameStore.Save(game); err != nil {
+		v.logger.Error("Failed to save game record: %v", err)
+	}
+	v.updateMessage(s, i, &domain.InteractionResponseData{Content: l.T(msgVotePassed, l.Command(vote.Command), len(vote.Yes), vote.Needed)})
+
+	return vote.Command, true
+}
+
+// Resume schedules the expiry of the vote kept in the game record, whose timer is
+// lost when the bot restarts. The vote already past its deadline expires at once.
+func (v *Voting) Resume(s domain.Session) {
+	v.mu.Lock()
+	game, err := v.gameStore.Load()
+	v.mu.Unlock()
+	if err != nil {
+		v.logger.Error("Failed to load game record: %v", err)
+		return
+	}
+
+	if game.Vote == nil {
+		return
+	}
+
+	// The language of the players is not kept, so the vote is closed in the default one
+	expiresAt := game.Vote.ExpiresAt
+	remaining := expiresAt.Sub(v.now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	v.logger.Info("Resuming vote for %s command until %s", game.Vote.Command, expiresAt.Format(time.RFC3339))
+	v.afterFunc(remaining, func() {
+		v.expire(s, localizerFor(nil), expiresAt)
+	})
+}
+
+// expire closes the vote when it is still open after its deadline
+func (v *Voting) expire(s domain.Session, l *localizer, expiresAt time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	game, err := v.gameStore.Load()
+	if err != nil {
+		v.logger.Error("Failed to load game record: %v", err)
+		return
+	}
+
+	vote := game.Vote
+	if vote == nil || !vote.ExpiresAt.Equal(expiresAt) {
+		return
+	}
+
+	v.logger.Info("Vote for %s command expired", vote.Command)
+	game.Vote = nil
+	if err := v.gameStore.Save(game); err != nil {
+		v.logger.Error("Failed to save game record: %v", err)
+	}
+
+	if err := s.SendMessage(vote.ChannelID, &domain.InteractionResponseData{Content: l.T(msgVoteExpired, l.Command(vote.Command))}); err != nil {
+		v.logger.Error("Failed to send message: %v", err)
+	}
+}
+
+// status shows the live counts of the vote with the buttons
+func (v *Voting) status(l *localizer, vote *domain.VoteRecord) *domain.InteractionResponseData {
+	return &domain.InteractionResponseData{
+		Content: l.T(msgVoteStatus, l.Command(vote.Command), len(vote.Yes), vote.Needed, len(vote.No), vote.ExpiresAt.Unix()),
+		Components: []*domain.ActionsRow{
+			{
+				Components: []domain.MessageComponent{
+					&domain.Button{Label: l.T(msgButtonVoteYes), Style: domain.SuccessButton, CustomID: CustomIDVoteYes},
+					&domain.Button{Label: l.T(msgButtonVoteNo), Style: domain.SecondaryButton, CustomID: CustomIDVoteNo},
+				},
+			},
+		},
+	}
+}
+
+func (v *Voting) respondEphemeral(s domain.Session, i *domain.InteractionCreate, content string) {
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseChannelMessageWithSource),
+		Data: &domain.InteractionResponseData{
+			Content: content,
+			Flags:   domain.MessageFlagsEphemeral,
+		},
+	}
+	if err := s.InteractionRespond(i, response); err != nil {
+		v.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// updateMessage updates the vote message, and removes its buttons unless they are given
+func (v *Voting) updateMessage(s domain.Session, i *domain.InteractionCreate, data *domain.InteractionResponseData) {
+	if data.Components == nil {
+		data.Components = []*domain.ActionsRow{}
+	}
+	response := &domain.InteractionResponse{
+		Type: int(domain.InteractionResponseUpdateMessage),
+		Data: data,
+	}
+	if err := s.InteractionRespond(i, response); err != nil {
+		v.logger.Error("Failed to respond to interaction: %v", err)
+	}
+}
+
+// channelSession posts the responses to the interaction as the messages to the channel,
+// which is used once the interaction itself is already responded
+type channelSession struct {
+	domain.Session
+	channelID string
+}
+
+func (s *channelSession) InteractionRespond(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+	// There is nothing to show while thinking
+	if r.Type == int(domain.InteractionResponseDeferredChannelMessageWithSource) || r.Data == nil {
+		return nil
+	}
+	return s.Session.SendMessage(s.channelID, r.Data)
+}
+
+func (s *channelSession) InteractionResponseEdit(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	return s.Session.SendMessage(s.channelID, data)
+}
+
+func (s *channelSession) FollowupMessage(i *domain.InteractionCreate, content string) error {
+	return s.Session.SendMessage(s.channelID, &domain.InteractionResponseData{Content: content})
+}
+
+func (s *channelSession) FollowupResponse(i *domain.InteractionCreate, data *domain.InteractionResponseData) error {
+	return s.Session.SendMessage(s.channelID, data)
+}
+
+// InteractionResponseDelete does nothing, since the original response is not the one of the messages
+func (s *channelSession) InteractionResponseDelete(i *domain.InteractionCreate) error {
+	return nil
+}
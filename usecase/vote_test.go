@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gong023/umi/domain"
+	"github.com/gong023/umi/infra/mock"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeFinisher records the sessions the command was finished with
+type fakeFinisher struct {
+	sessions []domain.Session
+}
+
+func (f *fakeFinisher) finish(s domain.Session, i *domain.InteractionCreate) {
+	f.sessions = append(f.sessions, s)
+}
+
+func newTestVoting(ctrl *gomock.Controller, gameStore domain.GameStore, now time.Time) (*Voting, *fakeFinisher) {
+	mockLogger := mock.NewMockLogger(ctrl)
+	mockLogger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+
+	voting := NewVoting(gameStore, mockLogger)
+	voting.now = func() time.Time { return now }
+	voting.afterFunc = func(d time.Duration, f func()) {}
+
+	finisher := &fakeFinisher{}
+	voting.register("giveup", finisher)
+
+	return voting, finisher
+}
+
+func TestVoting_Participants(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	voting, _ := newTestVoting(ctrl, nil, now)
+
+	game := &domain.Game{
+		Questions: []*domain.QuestionRecord{
+			{UserID: "player-a", AskedAt: now.Add(-time.Minute)},
+			{UserID: "player-b", AskedAt: now.Add(-time.Minute)},
+			{UserID: "player-c", AskedAt: now.Add(-time.Hour)},
+		},
+		Clues: []*domain.ClueRecord{
+			{UserID: "player-d", GivenAt: now.Add(-time.Minute)},
+		},
+	}
+
+	// The player who took part an hour ago is not counted
+	participants := voting.participants(game, "player-a")
+	if participants != 3 {
+		t.Errorf("Expected 3 participants, got %d", participants)
+	}
+	if needed := voting.needed(participants); needed != 2 {
+		t.Errorf("Expected 2 votes to be needed, got %d", needed)
+	}
+	if needed := voting.needed(0); needed != 1 {
+		t.Errorf("Expected at least 1 vote to be needed, got %d", needed)
+	}
+}
+
+func TestVoting_Start_Alone(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{Solution: "solution"}, nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	voting, finisher := newTestVoting(ctrl, mockGameStore, time.Now())
+
+	// The command is run at once without the other participants
+	voting.Start(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "player-a"}}, "giveup")
+	if len(finisher.sessions) != 1 || finisher.sessions[0] != mockSession {
+		t.Errorf("Expected the command to be finished with the session, got %d", len(finisher.sessions))
+	}
+}
+
+func TestVoting_Start_NoQuiz(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(&domain.Game{}, nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	voting, finisher := newTestVoting(ctrl, mockGameStore, time.Now())
+
+	// No vote is started without the quiz
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != "現在クイズが存在しません。`/出題` コマンドで新しいクイズを作成してください。" || r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected the ephemeral no quiz message, got %+v", r.Data)
+			}
+			return nil
+		})
+	voting.Start(mockSession, &domain.InteractionCreate{User: &domain.User{ID: "player-a"}}, "giveup")
+
+	if len(finisher.sessions) != 0 {
+		t.Errorf("Expected the command not to be finished, got %d", len(finisher.sessions))
+	}
+}
+
+func TestVoting_Start_And_Pass(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	game := &domain.Game{
+		Solution: "solution",
+		Questions: []*domain.QuestionRecord{
+			{UserID: "player-a", AskedAt: now},
+			{UserID: "player-b", AskedAt: now},
+			{UserID: "player-c", AskedAt: now},
+		},
+	}
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(game, nil).AnyTimes()
+	mockGameStore.EXPECT().Save(game).Return(nil).AnyTimes()
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	voting, finisher := newTestVoting(ctrl, mockGameStore, now)
+
+	// The vote is posted with the buttons
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Type != int(domain.InteractionResponseChannelMessageWithSource) || len(r.Data.Components) != 1 {
+				t.Errorf("Expected the vote with the buttons, got %+v", r.Data)
+			}
+			return nil
+		})
+	voting.Start(mockSession, &domain.InteractionCreate{ChannelID: "channel-id", User: &domain.User{ID: "player-a"}}, "giveup")
+
+	if game.Vote == nil || game.Vote.Needed != 2 || game.Vote.Voters != 3 || len(game.Vote.Yes) != 1 {
+		t.Fatalf("Expected the vote to be kept in the game record, got %+v", game.Vote)
+	}
+	if len(finisher.sessions) != 0 {
+		t.Errorf("Expected the command not to be finished before the vote passes")
+	}
+
+	// A vote against it updates the live counts
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Type != int(domain.InteractionResponseUpdateMessage) || len(r.Data.Components) != 1 {
+				t.Errorf("Expected the counts to be updated with the buttons, got %+v", r.Data)
+			}
+			return nil
+		})
+	voting.Handle(mockSession, &domain.InteractionCreate{
+		ChannelID: "channel-id",
+		User:      &domain.User{ID: "player-b"},
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDVoteNo},
+	})
+	if len(game.Vote.No) != 1 {
+		t.Errorf("Expected the vote against it to be recorded, got %+v", game.Vote)
+	}
+
+	// The player changes the vote, and the vote passes
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != "✅ `/ギブアップ` の投票が可決されました（2/2）。" || len(r.Data.Components) != 0 {
+				t.Errorf("Expected the vote to be closed, got %+v", r.Data)
+			}
+			return nil
+		})
+	voting.Handle(mockSession, &domain.InteractionCreate{
+		ChannelID: "channel-id",
+		User:      &domain.User{ID: "player-b"},
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDVoteYes},
+	})
+
+	if game.Vote != nil {
+		t.Errorf("Expected the vote to be cleared, got %+v", game.Vote)
+	}
+	if len(finisher.sessions) != 1 {
+		t.Fatalf("Expected the command to be finished once, got %d", len(finisher.sessions))
+	}
+
+	// The result is posted to the channel, since the interaction is already responded
+	mockSession.EXPECT().SendMessage("channel-id", gomock.Any()).Return(nil)
+	if err := editResponse(finisher.sessions[0], &domain.InteractionCreate{}, "正解は…"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestVoting_Handle_Expired(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	game := &domain.Game{
+		Vote: &domain.VoteRecord{Command: "giveup", Yes: []string{"player-a"}, Needed: 2, ExpiresAt: now.Add(-time.Second)},
+	}
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(game, nil).Times(2)
+	mockGameStore.EXPECT().Save(game).Return(nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	voting, finisher := newTestVoting(ctrl, mockGameStore, now)
+
+	// The expired vote is closed without running the command
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Content != "⌛ `/ギブアップ` の投票は期限切れになりました。" {
+				t.Errorf("Unexpected response: %+v", r.Data)
+			}
+			return nil
+		})
+	interaction := &domain.InteractionCreate{
+		User:      &domain.User{ID: "player-b"},
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDVoteYes},
+	}
+	voting.Handle(mockSession, interaction)
+
+	if game.Vote != nil || len(finisher.sessions) != 0 {
+		t.Errorf("Expected the vote to expire, got %+v", game.Vote)
+	}
+
+	// The buttons of the closed vote only tell that there is no vote
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Data.Flags&domain.MessageFlagsEphemeral == 0 {
+				t.Errorf("Expected an ephemeral response, got %+v", r.Data)
+			}
+			return nil
+		})
+	voting.Handle(mockSession, interaction)
+}
+
+func TestVoting_Handle_Rejected(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	game := &domain.Game{
+		Solution: "solution",
+		Vote:     &domain.VoteRecord{Command: "giveup", Yes: []string{"player-a"}, No: []string{"player-b"}, Needed: 2, Voters: 3, ChannelID: "channel-id", ExpiresAt: now.Add(time.Minute)},
+	}
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(game, nil)
+	mockGameStore.EXPECT().Save(game).Return(nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	voting, finisher := newTestVoting(ctrl, mockGameStore, now)
+
+	// The second vote against it leaves too few voters to pass it
+	mockSession.EXPECT().InteractionRespond(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(i *domain.InteractionCreate, r *domain.InteractionResponse) error {
+			if r.Type != int(domain.InteractionResponseUpdateMessage) || r.Data.Content != "❌ `/ギブアップ` の投票は否決されました（反対 2）。" || len(r.Data.Components) != 0 {
+				t.Errorf("Expected the vote to be closed as rejected, got %+v", r.Data)
+			}
+			return nil
+		})
+	voting.Handle(mockSession, &domain.InteractionCreate{
+		ChannelID: "channel-id",
+		User:      &domain.User{ID: "player-c"},
+		Component: &domain.MessageComponentInteractionData{CustomID: CustomIDVoteNo},
+	})
+
+	if game.Vote != nil || len(finisher.sessions) != 0 {
+		t.Errorf("Expected the vote to be rejected, got %+v", game.Vote)
+	}
+}
+
+func TestVoting_Resume(t *testing.T) {
+	// Create a mock controller
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+	game := &domain.Game{
+		Solution: "solution",
+		Vote:     &domain.VoteRecord{Command: "giveup", Yes: []string{"player-a"}, Needed: 2, ChannelID: "channel-id", ExpiresAt: expiresAt},
+	}
+
+	// Create a mock game store
+	mockGameStore := mock.NewMockGameStore(ctrl)
+	mockGameStore.EXPECT().Load().Return(game, nil).Times(2)
+	mockGameStore.EXPECT().Save(game).Return(nil)
+
+	// Create a mock session
+	mockSession := mock.NewMockSession(ctrl)
+
+	voting, _ := newTestVoting(ctrl, mockGameStore, now)
+
+	// The expiry of the kept vote is scheduled for the rest of its duration
+	var scheduled time.Duration
+	var expire func()
+	voting.afterFunc = func(d time.Duration, f func()) {
+		scheduled = d
+		expire = f
+	}
+	voting.Resume(mockSession)
+
+	if expire == nil || scheduled != time.Minute {
+		t.Fatalf("Expected the expiry to be scheduled in a minute, got %v", scheduled)
+	}
+
+	// The vote is closed in the channel once it expires
+	mockSession.EXPECT().SendMessage("channel-id", gomock.Any()).DoAndReturn(
+		func(channelID string, data *domain.InteractionResponseData) error {
+			if data.Content != "⌛ `/ギブアップ` の投票は期限切れになりました。" {
+				t.Errorf("Unexpected message: %+v", data)
+			}
+			return nil
+		})
+	expire()
+
+	if game.Vote != nil {
+		t.Errorf("Expected the vote to expire, got %+v", game.Vote)
+	}
+}